@@ -0,0 +1,283 @@
+package deluge
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/naposproject/go-deluge/metainfo"
+)
+
+// ErrTorrentExists is returned alongside the existing info hash, when it is
+// known, if the torrent being added is already in the session
+var ErrTorrentExists = errors.New("torrent already added")
+
+// MaxTorrentSize is the largest .torrent, in bytes, the add methods will read
+// and upload
+var MaxTorrentSize int64 = 10 << 20
+
+// existsPattern matches the error Deluge 2 raises for a duplicate torrent
+var existsPattern = regexp.MustCompile(`already in session \(([0-9a-fA-F]{40})\)`)
+
+// AddOptions holds the options applied to a torrent as it is added. The zero
+// value leaves every option at the daemon's default; only non-nil fields are
+// sent, so false and 0 can override a daemon default.
+type AddOptions struct {
+	// DownloadLocation is the directory the torrent data is saved to
+	DownloadLocation string `json:"download_location,omitempty"`
+	// MoveCompletedPath moves the data here once the download completes;
+	// setting it turns MoveCompleted on unless MoveCompleted is set
+	MoveCompletedPath string `json:"move_completed_path,omitempty"`
+	MoveCompleted     *bool  `json:"move_completed,omitempty"`
+	// AddPaused adds the torrent in the paused state
+	AddPaused *bool `json:"add_paused,omitempty"`
+	// FilePriorities holds one priority per file in the torrent (0 skips the
+	// file, 1 is normal and 7 is the highest)
+	FilePriorities []int `json:"file_priorities,omitempty"`
+	// Speeds are in KiB/s; -1 is unlimited
+	MaxDownloadSpeed *float64 `json:"max_download_speed,omitempty"`
+	MaxUploadSpeed   *float64 `json:"max_upload_speed,omitempty"`
+	// Connection limits; -1 is unlimited
+	MaxConnections *int `json:"max_connections,omitempty"`
+	MaxUploadSlots *int `json:"max_upload_slots,omitempty"`
+	// SeedMode skips the hash check and assumes the data is already complete
+	SeedMode            *bool `json:"seed_mode,omitempty"`
+	SequentialDownload  *bool `json:"sequential_download,omitempty"`
+	PrioritizeFirstLast *bool `json:"prioritize_first_last_pieces,omitempty"`
+	SuperSeeding        *bool `json:"super_seeding,omitempty"`
+	// AutoManaged lets the queue decide when the torrent runs
+	AutoManaged *bool `json:"auto_managed,omitempty"`
+
+	// Label is applied with the Label plugin once the torrent has been added
+	Label string `json:"-"`
+	// Wait, if non-zero, blocks until the torrent shows up in the session or
+	// the duration elapses
+	Wait time.Duration `json:"-"`
+
+	// Headers are sent with the request when an http(s) .torrent URL is
+	// downloaded, e.g. cookies or authorization for private trackers
+	Headers map[string]string `json:"-"`
+	// FetchLocally downloads http(s) .torrent URLs from this process and
+	// uploads the file, for when the daemon cannot reach the URL itself
+	FetchLocally bool `json:"-"`
+}
+
+// options returns the options dict sent to the daemon
+func (opts *AddOptions) options() AddOptions {
+	if opts == nil {
+		return AddOptions{}
+	}
+	o := *opts
+	if o.MoveCompletedPath != "" && o.MoveCompleted == nil {
+		o.MoveCompleted = Bool(true)
+	}
+	return o
+}
+
+// added inspects the response of an add call. hash is the locally computed
+// info hash and is used when the daemon does not report one.
+func (c *Client) added(res StringResponse, hash string, opts *AddOptions) (string, error) {
+	if res.Error.Code != 0 {
+		if m := existsPattern.FindStringSubmatch(res.Error.Message); m != nil {
+			return m[1], ErrTorrentExists
+		}
+		return "", fmt.Errorf("Error adding torrent: %s", res.Error.Message)
+	}
+	//Deluge 1.3 returns null instead of raising for duplicates
+	if res.Result == "" {
+		return hash, ErrTorrentExists
+	}
+
+	return res.Result, c.finish(res.Result, opts)
+}
+
+// finish applies the options that are handled after a torrent has been added
+func (c *Client) finish(hash string, opts *AddOptions) error {
+	if opts != nil && opts.Label != "" {
+		err := c.SetTorrentLabel(hash, opts.Label)
+		if err != nil {
+			return err
+		}
+	}
+	if opts != nil && opts.Wait > 0 {
+		err := c.WaitForTorrent(hash, opts.Wait)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WaitForTorrent blocks until the torrent specified by info hash appears in
+// the session or the timeout elapses
+func (c *Client) WaitForTorrent(hash string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		torrent, err := c.GetTorrent(hash)
+		if err != nil {
+			return err
+		}
+		if torrent.Hash != "" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Error waiting for torrent: %s not added after %s", hash, timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// AddTorrent adds the torrent specified by url or magnet link and returns its
+// info hash. Magnet links are handed to the daemon as is, http(s) URLs are
+// downloaded by the daemon unless opts.FetchLocally is set. opts may be nil.
+// If the torrent is already in the session the existing hash is returned with
+// ErrTorrentExists. Deluge 1.3 does not report the hash of a duplicate URL the
+// daemon downloaded itself, so it is empty then; set opts.FetchLocally to have
+// it computed from the downloaded file.
+func (c *Client) AddTorrent(uri string, opts *AddOptions) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	switch u.Scheme {
+	case "magnet":
+		m, err := metainfo.ParseMagnet(uri)
+		if err != nil {
+			return "", fmt.Errorf("Error adding torrent: %w", err)
+		}
+		return c.addTorrentMagnet(uri, m, opts)
+	case "http", "https":
+		if opts != nil && opts.FetchLocally {
+			return c.fetchTorrent(u, opts)
+		}
+		return c.addTorrentURL(uri, opts)
+	}
+
+	return "", fmt.Errorf("Error adding torrent: unsupported scheme %q", u.Scheme)
+}
+
+// addTorrentMagnet adds a parsed magnet link via core.add_torrent_magnet
+func (c *Client) addTorrentMagnet(uri string, m *metainfo.Magnet, opts *AddOptions) (string, error) {
+	p, err := params(uri, opts.options())
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	var res StringResponse
+	err = c.action("core.add_torrent_magnet", p, &res)
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	return c.added(res, m.ID(), opts)
+}
+
+// addTorrentURL has the daemon download and add a .torrent via
+// core.add_torrent_url. The .torrent never passes through this process, so
+// a duplicate on Deluge 1.3 is reported without its hash.
+func (c *Client) addTorrentURL(uri string, opts *AddOptions) (string, error) {
+	headers := map[string]string{}
+	if opts != nil && opts.Headers != nil {
+		headers = opts.Headers
+	}
+	p, err := params(uri, opts.options(), headers)
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	var res StringResponse
+	err = c.action("core.add_torrent_url", p, &res)
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	return c.added(res, "", opts)
+}
+
+// fetchTorrent downloads a .torrent from this process and uploads it to the
+// daemon via core.add_torrent_file
+func (c *Client) fetchTorrent(u *url.URL, opts *AddOptions) (string, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("Error downloading torrent: %w", err)
+	}
+	for header, value := range opts.Headers {
+		req.Header.Set(header, value)
+	}
+
+	client := &http.Client{Timeout: time.Second * 30}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error downloading torrent: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("Error downloading torrent: %s", res.Status)
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = "download.torrent"
+	}
+	return c.AddTorrentReader(name, res.Body, opts)
+}
+
+// AddTorrentFile adds the torrent specified by a file on disk and returns its
+// info hash. opts may be nil. If the torrent is already in the session the
+// existing hash is returned with ErrTorrentExists.
+func (c *Client) AddTorrentFile(torrentpath string, opts *AddOptions) (string, error) {
+	f, err := os.Open(torrentpath)
+	if err != nil {
+		return "", fmt.Errorf("Error opening torrent file: %w", err)
+	}
+	defer f.Close()
+
+	return c.AddTorrentReader(filepath.Base(torrentpath), f, opts)
+}
+
+// AddTorrentReader adds the .torrent read from r, named name, and returns its
+// info hash. At most MaxTorrentSize bytes are read. opts may be nil.
+func (c *Client) AddTorrentReader(name string, r io.Reader, opts *AddOptions) (string, error) {
+	blob, err := ioutil.ReadAll(io.LimitReader(r, MaxTorrentSize+1))
+	if err != nil {
+		return "", fmt.Errorf("Error reading torrent file: %w", err)
+	}
+
+	return c.AddTorrentBytes(name, blob, opts)
+}
+
+// AddTorrentBytes adds the .torrent held in blob, named name, and returns its
+// info hash. The torrent is validated before it is uploaded. opts may be nil.
+func (c *Client) AddTorrentBytes(name string, blob []byte, opts *AddOptions) (string, error) {
+	if int64(len(blob)) > MaxTorrentSize {
+		return "", fmt.Errorf("Error adding torrent: %s exceeds %d bytes", name, MaxTorrentSize)
+	}
+	mi, err := metainfo.Parse(blob)
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: invalid torrent %s: %w", name, err)
+	}
+
+	p, err := params(name, base64.StdEncoding.EncodeToString(blob), opts.options())
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	var res StringResponse
+	err = c.action("core.add_torrent_file", p, &res)
+	if err != nil {
+		return "", fmt.Errorf("Error adding torrent: %w", err)
+	}
+
+	return c.added(res, mi.ID(), opts)
+}
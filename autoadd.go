@@ -0,0 +1,208 @@
+package deluge
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// WatchDir is an AutoAdd plugin watch folder. Torrent files dropped into
+// Path are added to the daemon with the settings below; nil fields leave the
+// daemon's defaults in place.
+type WatchDir struct {
+	// ID is assigned by the plugin; it is ignored by AddWatchDir
+	ID   int
+	Path string
+	// Enabled watches the folder; nil means true, so a WatchDir with just a
+	// Path is watched. Set it to Bool(false) to add a folder paused.
+	Enabled *bool
+
+	DownloadLocation *string
+	// MoveCompletedPath moves finished torrents to the given path
+	MoveCompletedPath *string
+	// Label is applied when the Label plugin is enabled
+	Label      *string
+	AddPaused  *bool
+	QueueToTop *bool
+	// Owner is the daemon user the torrents are added for (Deluge 2 only)
+	Owner string
+
+	// Raw holds every option returned by the plugin
+	Raw map[string]interface{}
+}
+
+// options returns the plugin options describing w. AutoAdd only applies an
+// option when its "<option>_toggle" key is set.
+func (w WatchDir) options() map[string]interface{} {
+	opts := map[string]interface{}{
+		"path":    w.Path,
+		"enabled": w.Enabled == nil || *w.Enabled,
+	}
+
+	opts["download_location_toggle"] = w.DownloadLocation != nil
+	if w.DownloadLocation != nil {
+		opts["download_location"] = *w.DownloadLocation
+	}
+	opts["move_completed_toggle"] = w.MoveCompletedPath != nil
+	if w.MoveCompletedPath != nil {
+		opts["move_completed"] = true
+		opts["move_completed_path"] = *w.MoveCompletedPath
+	}
+	opts["label_toggle"] = w.Label != nil
+	if w.Label != nil {
+		opts["label"] = *w.Label
+	}
+	opts["add_paused_toggle"] = w.AddPaused != nil
+	if w.AddPaused != nil {
+		opts["add_paused"] = *w.AddPaused
+	}
+	opts["queue_to_top_toggle"] = w.QueueToTop != nil
+	if w.QueueToTop != nil {
+		opts["queue_to_top"] = *w.QueueToTop
+	}
+	//Deluge 1.3 rejects the owner key
+	if w.Owner != "" {
+		opts["owner"] = w.Owner
+	}
+
+	return opts
+}
+
+// newWatchDir builds a WatchDir from the plugin's options
+func newWatchDir(id int, raw map[string]interface{}) WatchDir {
+	w := WatchDir{ID: id, Raw: raw}
+	w.Path, _ = raw["path"].(string)
+	enabled, _ := raw["enabled"].(bool)
+	w.Enabled = Bool(enabled)
+	w.Owner, _ = raw["owner"].(string)
+
+	toggled := func(key string) bool {
+		on, _ := raw[key+"_toggle"].(bool)
+		return on
+	}
+	if v, ok := raw["download_location"].(string); ok && toggled("download_location") {
+		w.DownloadLocation = String(v)
+	}
+	if v, ok := raw["move_completed_path"].(string); ok && toggled("move_completed") && raw["move_completed"] == true {
+		w.MoveCompletedPath = String(v)
+	}
+	if v, ok := raw["label"].(string); ok && toggled("label") {
+		w.Label = String(v)
+	}
+	if v, ok := raw["add_paused"].(bool); ok && toggled("add_paused") {
+		w.AddPaused = Bool(v)
+	}
+	if v, ok := raw["queue_to_top"].(bool); ok && toggled("queue_to_top") {
+		w.QueueToTop = Bool(v)
+	}
+
+	return w
+}
+
+// GetWatchDirs returns the AutoAdd watch folders ordered by ID
+func (c *Client) GetWatchDirs() ([]WatchDir, error) {
+	err := c.requirePlugin("AutoAdd")
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Result map[string]map[string]interface{} `json:"result"`
+		Error  RpcError                          `json:"error"`
+	}
+	err = c.action("autoadd.get_watchdirs", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting watch folders: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting watch folders: %s", res.Error.Message)
+	}
+
+	dirs := make([]WatchDir, 0, len(res.Result))
+	for key, raw := range res.Result {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting watch folders: invalid id %q", key)
+		}
+		dirs = append(dirs, newWatchDir(id, raw))
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].ID < dirs[j].ID })
+
+	return dirs, nil
+}
+
+// AddWatchDir creates a watch folder and returns its ID. The path must exist
+// on the daemon's machine and not be watched already.
+func (c *Client) AddWatchDir(dir WatchDir) (int, error) {
+	err := c.requirePlugin("AutoAdd")
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := params(dir.options())
+	if err != nil {
+		return 0, fmt.Errorf("Error adding watch folder: %w", err)
+	}
+
+	var res struct {
+		Result int      `json:"result"`
+		Error  RpcError `json:"error"`
+	}
+	err = c.action("autoadd.add", p, &res)
+	if err != nil {
+		return 0, fmt.Errorf("Error adding watch folder: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return 0, fmt.Errorf("Error adding watch folder: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// UpdateWatchDir replaces the settings of the watch folder with dir.ID
+func (c *Client) UpdateWatchDir(dir WatchDir) error {
+	return c.watchDirAction("autoadd.set_options", "Error updating watch folder", dir.ID, dir.options())
+}
+
+// RemoveWatchDir deletes a watch folder; torrents already added are kept
+func (c *Client) RemoveWatchDir(id int) error {
+	return c.watchDirAction("autoadd.remove", "Error removing watch folder", id)
+}
+
+// EnableWatchDir starts watching a watch folder
+func (c *Client) EnableWatchDir(id int) error {
+	return c.watchDirAction("autoadd.enable_watchdir", "Error enabling watch folder", id)
+}
+
+// DisableWatchDir stops watching a watch folder without deleting it
+func (c *Client) DisableWatchDir(id int) error {
+	return c.watchDirAction("autoadd.disable_watchdir", "Error disabling watch folder", id)
+}
+
+// watchDirAction calls an AutoAdd method taking a watch folder ID and
+// optional further parameters
+func (c *Client) watchDirAction(method string, context string, id int, extra ...interface{}) error {
+	err := c.requirePlugin("AutoAdd")
+	if err != nil {
+		return err
+	}
+
+	p, err := params(append([]interface{}{id}, extra...)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", context, err)
+	}
+
+	var res struct {
+		Result interface{} `json:"result"`
+		Error  RpcError    `json:"error"`
+	}
+	err = c.action(method, p, &res)
+	if err != nil {
+		return fmt.Errorf("%s: %w", context, err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("%s: %s", context, res.Error.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package deluge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWatchDirOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  WatchDir
+		want map[string]interface{}
+	}{
+		{
+			name: "path only",
+			dir:  WatchDir{Path: "/watch"},
+			want: map[string]interface{}{
+				"path": "/watch", "enabled": true,
+				"download_location_toggle": false, "move_completed_toggle": false,
+				"label_toggle": false, "add_paused_toggle": false, "queue_to_top_toggle": false,
+			},
+		},
+		{
+			name: "disabled",
+			dir:  WatchDir{Path: "/watch", Enabled: Bool(false), AddPaused: Bool(false), Label: String("tv")},
+			want: map[string]interface{}{
+				"path": "/watch", "enabled": false,
+				"download_location_toggle": false, "move_completed_toggle": false,
+				"label_toggle": true, "label": "tv",
+				"add_paused_toggle": true, "add_paused": false,
+				"queue_to_top_toggle": false,
+			},
+		},
+		{
+			name: "move completed",
+			dir:  WatchDir{Path: "/watch", Enabled: Bool(true), MoveCompletedPath: String("/done"), Owner: "alice"},
+			want: map[string]interface{}{
+				"path": "/watch", "enabled": true,
+				"download_location_toggle": false,
+				"move_completed_toggle":    true, "move_completed": true, "move_completed_path": "/done",
+				"label_toggle": false, "add_paused_toggle": false, "queue_to_top_toggle": false,
+				"owner": "alice",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		opts := tt.dir.options()
+		if !reflect.DeepEqual(opts, tt.want) {
+			t.Errorf("%s: options() = %v, want %v", tt.name, opts, tt.want)
+		}
+
+		//Reading the options back gives the same settings
+		w := newWatchDir(1, opts)
+		w.ID, w.Raw = 0, nil
+		if tt.dir.Enabled == nil {
+			tt.dir.Enabled = Bool(true)
+		}
+		if !reflect.DeepEqual(w, tt.dir) {
+			t.Errorf("%s: newWatchDir(options()) = %+v, want %+v", tt.name, w, tt.dir)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package deluge
+
+import (
+	"fmt"
+	"time"
+)
+
+// BlocklistConfig holds the Blocklist plugin settings
+type BlocklistConfig struct {
+	URL            string `json:"url"`
+	LoadOnStart    bool   `json:"load_on_start"`
+	CheckAfterDays int    `json:"check_after_days"`
+	// ListType and ListCompression are detected from the downloaded list
+	// when left empty
+	ListType        string `json:"list_type"`
+	ListCompression string `json:"list_compression"`
+	// Timeout is in seconds
+	Timeout  int `json:"timeout"`
+	TryTimes int `json:"try_times"`
+	// Whitelisted are IP ranges never blocked (Deluge 2 only)
+	Whitelisted []string `json:"whitelisted"`
+}
+
+// BlocklistStatus reports the state of the Blocklist plugin
+type BlocklistStatus struct {
+	// State is "Idle", "Downloading" or "Importing"
+	State      string
+	NumBlocked int
+	// NumWhitelisted is always 0 on Deluge 1.3
+	NumWhitelisted int
+	// Progress is the fraction of the list downloaded or imported
+	Progress   float64
+	URL        string
+	Size       int64
+	Type       string
+	LastUpdate time.Time
+}
+
+// GetBlocklistConfig returns the Blocklist plugin settings
+func (c *Client) GetBlocklistConfig() (BlocklistConfig, error) {
+	err := c.requirePlugin("Blocklist")
+	if err != nil {
+		return BlocklistConfig{}, err
+	}
+
+	var res struct {
+		Result BlocklistConfig `json:"result"`
+		Error  RpcError        `json:"error"`
+	}
+	err = c.action("blocklist.get_config", "", &res)
+	if err != nil {
+		return BlocklistConfig{}, fmt.Errorf("Error getting blocklist config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return BlocklistConfig{}, fmt.Errorf("Error getting blocklist config: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// SetBlocklistConfig replaces the Blocklist plugin settings
+func (c *Client) SetBlocklistConfig(cfg BlocklistConfig) error {
+	err := c.requirePlugin("Blocklist")
+	if err != nil {
+		return err
+	}
+
+	if cfg.Whitelisted == nil {
+		cfg.Whitelisted = []string{}
+	}
+	p, err := params(cfg)
+	if err != nil {
+		return fmt.Errorf("Error setting blocklist config: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("blocklist.set_config", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error setting blocklist config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error setting blocklist config: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// CheckBlocklistImport downloads and imports the blocklist if it is out of
+// date, or regardless when force is set. The call returns once the import is
+// done, which for large lists can exceed the client's timeout; use
+// GetBlocklistStatus to follow the import in that case.
+func (c *Client) CheckBlocklistImport(force bool) error {
+	err := c.requirePlugin("Blocklist")
+	if err != nil {
+		return err
+	}
+
+	p, err := params(force)
+	if err != nil {
+		return fmt.Errorf("Error importing blocklist: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("blocklist.check_import", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error importing blocklist: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error importing blocklist: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// GetBlocklistStatus returns the Blocklist plugin's current state
+func (c *Client) GetBlocklistStatus() (BlocklistStatus, error) {
+	err := c.requirePlugin("Blocklist")
+	if err != nil {
+		return BlocklistStatus{}, err
+	}
+
+	var res struct {
+		Result struct {
+			State        string      `json:"state"`
+			NumBlocked   int         `json:"num_blocked"`
+			NumWhited    int         `json:"num_whited"`
+			FileProgress float64     `json:"file_progress"`
+			FileURL      string      `json:"file_url"`
+			FileSize     int64       `json:"file_size"`
+			FileType     string      `json:"file_type"`
+			FileDate     interface{} `json:"file_date"`
+		} `json:"result"`
+		Error RpcError `json:"error"`
+	}
+	err = c.action("blocklist.get_status", "", &res)
+	if err != nil {
+		return BlocklistStatus{}, fmt.Errorf("Error getting blocklist status: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return BlocklistStatus{}, fmt.Errorf("Error getting blocklist status: %s", res.Error.Message)
+	}
+
+	status := BlocklistStatus{
+		State:          res.Result.State,
+		NumBlocked:     res.Result.NumBlocked,
+		NumWhitelisted: res.Result.NumWhited,
+		Progress:       res.Result.FileProgress,
+		URL:            res.Result.FileURL,
+		Size:           res.Result.FileSize,
+		Type:           res.Result.FileType,
+	}
+	//The date is 0, or "" on Deluge 1.3, until a list has been imported
+	if date, ok := res.Result.FileDate.(float64); ok && date > 0 {
+		status.LastUpdate = time.Unix(int64(date), 0)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,272 @@
+package deluge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/naposproject/go-deluge/metainfo"
+)
+
+// BulkAddBatchSize is the number of torrents sent per core.add_torrent_files
+// call
+var BulkAddBatchSize = 50
+
+// BulkAddConcurrency bounds the number of simultaneous single adds used when
+// the daemon does not support core.add_torrent_files
+var BulkAddConcurrency = 4
+
+// AddResult reports the outcome of adding a single file in a bulk add
+type AddResult struct {
+	Path string
+	Hash string
+	Err  error
+}
+
+// bulkTorrent is a validated .torrent ready for upload
+type bulkTorrent struct {
+	index int
+	name  string
+	blob  []byte
+}
+
+// AddTorrentFiles adds many .torrent files at once and returns one result per
+// path, in order. Deluge 2 daemons receive them in batches through
+// core.add_torrent_files; older daemons get concurrent single adds, as does
+// a daemon of unknown version that rejects the batch call.
+// opts may be nil and applies to every torrent.
+func (c *Client) AddTorrentFiles(paths []string, opts *AddOptions) []AddResult {
+	results := make([]AddResult, len(paths))
+	var pending []bulkTorrent
+
+	for i, path := range paths {
+		results[i].Path = path
+		blob, err := readTorrent(path)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		mi, err := metainfo.Parse(blob)
+		if err != nil {
+			results[i].Err = fmt.Errorf("Error adding torrent: invalid torrent %s: %w", path, err)
+			continue
+		}
+		results[i].Hash = mi.ID()
+		pending = append(pending, bulkTorrent{index: i, name: filepath.Base(path), blob: blob})
+	}
+
+	for len(pending) > 0 {
+		n := BulkAddBatchSize
+		if n <= 0 || n > len(pending) {
+			n = len(pending)
+		}
+		batch := pending[:n]
+		pending = pending[n:]
+
+		version := c.Version()
+		if version.IsV1() {
+			c.addTorrentsSingly(batch, opts, results)
+			continue
+		}
+		errs, err := c.addTorrentBatch(batch, opts)
+		if err != nil && !version.Known() {
+			//Most likely a 1.3 daemon without core.add_torrent_files
+			c.addTorrentsSingly(batch, opts, results)
+			continue
+		}
+		if err != nil {
+			for _, t := range batch {
+				results[t.index].Err = fmt.Errorf("Error adding torrent: %w", err)
+			}
+			continue
+		}
+
+		matched := make([]bool, len(errs))
+		for _, t := range batch {
+			hash := results[t.index].Hash
+			for i, msg := range errs {
+				if strings.Contains(strings.ToLower(msg), hash) {
+					matched[i] = true
+					if existsPattern.MatchString(msg) {
+						results[t.index].Err = ErrTorrentExists
+					} else {
+						results[t.index].Err = fmt.Errorf("Error adding torrent: %s", msg)
+					}
+				}
+			}
+		}
+
+		//Errors without a hash, e.g. decode failures, cannot be tied to a
+		//file, so check which of the remaining torrents actually arrived
+		var unmatched []string
+		for i, msg := range errs {
+			if !matched[i] {
+				unmatched = append(unmatched, msg)
+			}
+		}
+		if len(unmatched) > 0 {
+			c.verifyBatch(batch, results, strings.Join(unmatched, "; "))
+		}
+
+		for _, t := range batch {
+			if results[t.index].Err == nil {
+				results[t.index].Err = c.finish(results[t.index].Hash, opts)
+			}
+		}
+	}
+
+	return results
+}
+
+// AddTorrentDir adds every file below dir whose name matches the glob pattern,
+// "*.torrent" if empty, using AddTorrentFiles
+func (c *Client) AddTorrentDir(dir string, pattern string, opts *AddOptions) ([]AddResult, error) {
+	if pattern == "" {
+		pattern = "*.torrent"
+	}
+
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		ok, err := filepath.Match(pattern, info.Name())
+		if err != nil {
+			return err
+		}
+		if ok {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error reading torrent directory: %w", err)
+	}
+
+	return c.AddTorrentFiles(paths, opts), nil
+}
+
+// readTorrent reads a .torrent from disk, bounded by MaxTorrentSize
+func readTorrent(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening torrent file: %w", err)
+	}
+	if info.Size() > MaxTorrentSize {
+		return nil, fmt.Errorf("Error adding torrent: %s exceeds %d bytes", path, MaxTorrentSize)
+	}
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading torrent file: %w", err)
+	}
+	return blob, nil
+}
+
+// addTorrentBatch sends a batch through core.add_torrent_files and returns the
+// error messages reported by the daemon
+func (c *Client) addTorrentBatch(batch []bulkTorrent, opts *AddOptions) ([]string, error) {
+	files := make([][]interface{}, len(batch))
+	for i, t := range batch {
+		files[i] = []interface{}{t.name, base64.StdEncoding.EncodeToString(t.blob), opts.options()}
+	}
+	p, err := params(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Result []json.RawMessage `json:"result"`
+		Error  RpcError          `json:"error"`
+	}
+	err = c.action("core.add_torrent_files", p, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("%s", res.Error.Message)
+	}
+
+	var errs []string
+	for _, raw := range res.Result {
+		var msg string
+		if json.Unmarshal(raw, &msg) != nil {
+			msg = string(raw)
+		}
+		errs = append(errs, msg)
+	}
+	return errs, nil
+}
+
+// verifyBatch fails every torrent of the batch without an error yet that is
+// not in the session, using msg, the daemon's unattributed errors, as reason
+func (c *Client) verifyBatch(batch []bulkTorrent, results []AddResult, msg string) {
+	var hashes []string
+	for _, t := range batch {
+		if results[t.index].Err == nil {
+			hashes = append(hashes, results[t.index].Hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return
+	}
+
+	p, err := params(map[string][]string{"id": hashes}, []string{"hash"})
+	var res struct {
+		Result map[string]json.RawMessage `json:"result"`
+		Error  RpcError                   `json:"error"`
+	}
+	if err == nil {
+		err = c.action("core.get_torrents_status", p, &res)
+	}
+	if err == nil && res.Error.Code != 0 {
+		err = res.Error
+	}
+
+	for _, t := range batch {
+		r := &results[t.index]
+		if r.Err != nil {
+			continue
+		}
+		if err != nil {
+			r.Err = fmt.Errorf("Error adding torrent: %s (unable to verify: %w)", msg, err)
+		} else if _, ok := res.Result[r.Hash]; !ok {
+			r.Err = fmt.Errorf("Error adding torrent: %s", msg)
+		}
+	}
+}
+
+// addTorrentsSingly adds each torrent of the batch with core.add_torrent_file,
+// at most BulkAddConcurrency at a time
+func (c *Client) addTorrentsSingly(batch []bulkTorrent, opts *AddOptions, results []AddResult) {
+	n := BulkAddConcurrency
+	if n <= 0 {
+		n = 1
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+
+	for _, t := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t bulkTorrent) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			hash, err := c.AddTorrentBytes(t.name, t.blob, opts)
+			if hash != "" {
+				results[t.index].Hash = hash
+			}
+			results[t.index].Err = err
+		}(t)
+	}
+
+	wg.Wait()
+}
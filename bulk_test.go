@@ -0,0 +1,168 @@
+package deluge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naposproject/go-deluge/metainfo"
+)
+
+const (
+	bulkV1     = "414e6d9bab12b00e182c996e601341300b9cdc72"
+	bulkV2     = "f5c45fbdc7ab75176d3e2a6e9b00c3c6d1902ec8"
+	bulkHybrid = "a9dbd6865a5926d14e112d548f91e008b7105b5b"
+)
+
+// addedHash returns the ID of the torrent uploaded in an add call's params
+func addedHash(params []json.RawMessage) string {
+	var blob string
+	json.Unmarshal(params[1], &blob)
+	b, _ := base64.StdEncoding.DecodeString(blob)
+	mi, err := metainfo.Parse(b)
+	if err != nil {
+		return ""
+	}
+	return mi.ID()
+}
+
+func TestAddTorrentFiles(t *testing.T) {
+	paths := []string{
+		filepath.Join("metainfo", "testdata", "v1.torrent"),
+		filepath.Join("metainfo", "testdata", "v2.torrent"),
+		filepath.Join("metainfo", "testdata", "hybrid.torrent"),
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		// errors is the result of core.add_torrent_files; nil leaves the
+		// method out, as on Deluge 1.3
+		errors interface{}
+		// session lists the torrents core.get_torrents_status reports, or
+		// nil to fail the lookup
+		session []string
+		batch   bool
+		single  int
+		want    []string
+	}{
+		{
+			name: "all added", version: "2.1.1", errors: []string{},
+			batch: true, want: []string{"", "", ""},
+		},
+		{
+			name: "errors matched by hash", version: "2.1.1",
+			errors: []string{
+				"Torrent already in session (" + bulkV2 + ").",
+				"Unable to add " + strings.ToUpper(bulkHybrid) + ": out of disk",
+			},
+			batch: true, want: []string{"", ErrTorrentExists.Error(), "out of disk"},
+		},
+		{
+			name: "unattributed error verified", version: "2.1.1",
+			errors:  []string{"Unable to decode torrent"},
+			session: []string{bulkV1, bulkHybrid},
+			batch:   true, want: []string{"", "Unable to decode torrent", ""},
+		},
+		{
+			name: "unattributed error next to a matched one", version: "2.1.1",
+			errors:  []string{"Torrent already in session (" + bulkV1 + ").", "Unable to decode torrent"},
+			session: []string{bulkV1},
+			batch:   true, want: []string{ErrTorrentExists.Error(), "Unable to decode torrent", "Unable to decode torrent"},
+		},
+		{
+			name: "unattributed error unverifiable", version: "2.1.1",
+			errors: []string{"Unable to decode torrent"},
+			batch:  true, want: []string{"unable to verify", "unable to verify", "unable to verify"},
+		},
+		{
+			name: "batch rejected", version: "2.1.1", errors: RpcError{Message: "Daemon gone", Code: 1},
+			batch: true, want: []string{"Daemon gone", "Daemon gone", "Daemon gone"},
+		},
+		{
+			name: "deluge 1.3", version: "1.3.15",
+			single: 3, want: []string{"", "", ""},
+		},
+		{
+			name: "unknown version without the batch call", version: "",
+			single: 3, want: []string{"", "", ""},
+		},
+		{
+			name: "unknown version with the batch call", version: "", errors: []string{},
+			batch: true, want: []string{"", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		f := newFakeDeluge(tt.version)
+		if tt.version == "" {
+			f.handle("daemon.info", func([]json.RawMessage) interface{} {
+				return RpcError{Message: "Not connected", Code: 1}
+			})
+		}
+		if tt.errors != nil {
+			result := tt.errors
+			f.handle("core.add_torrent_files", func([]json.RawMessage) interface{} { return result })
+		}
+		f.handle("core.add_torrent_file", func(params []json.RawMessage) interface{} { return addedHash(params) })
+		session := tt.session
+		f.handle("core.get_torrents_status", func([]json.RawMessage) interface{} {
+			if session == nil {
+				return RpcError{Message: "Lookup failed", Code: 1}
+			}
+			status := map[string]interface{}{}
+			for _, hash := range session {
+				status[hash] = map[string]string{"hash": hash}
+			}
+			return status
+		})
+		c := newTestClient(t, f)
+
+		results := c.AddTorrentFiles(paths, nil)
+		if len(results) != len(paths) {
+			t.Fatalf("%s: %d results, want %d", tt.name, len(results), len(paths))
+		}
+		for i, r := range results {
+			if r.Path != paths[i] {
+				t.Errorf("%s: results[%d].Path = %q", tt.name, i, r.Path)
+			}
+			if want := []string{bulkV1, bulkV2, bulkHybrid}[i]; r.Hash != want {
+				t.Errorf("%s: results[%d].Hash = %q, want %q", tt.name, i, r.Hash, want)
+			}
+			switch {
+			case tt.want[i] == "" && r.Err != nil:
+				t.Errorf("%s: results[%d] unexpected error %v", tt.name, i, r.Err)
+			case tt.want[i] == ErrTorrentExists.Error() && r.Err != ErrTorrentExists:
+				t.Errorf("%s: results[%d] error %v, want ErrTorrentExists", tt.name, i, r.Err)
+			case tt.want[i] != "" && (r.Err == nil || !strings.Contains(r.Err.Error(), tt.want[i])):
+				t.Errorf("%s: results[%d] error %v, want error containing %q", tt.name, i, r.Err, tt.want[i])
+			}
+		}
+
+		if n := len(f.called("core.add_torrent_files")); (n > 0) != tt.batch {
+			t.Errorf("%s: core.add_torrent_files called %d times", tt.name, n)
+		}
+		if n := len(f.called("core.add_torrent_file")); n != tt.single {
+			t.Errorf("%s: core.add_torrent_file called %d times, want %d", tt.name, n, tt.single)
+		}
+	}
+}
+
+func TestAddTorrentFilesInvalid(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.add_torrent_files", func([]json.RawMessage) interface{} { return []string{} })
+	c := newTestClient(t, f)
+
+	results := c.AddTorrentFiles([]string{"missing.torrent", "README.md"}, nil)
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "Error opening torrent file") {
+		t.Errorf("missing file: error %v", results[0].Err)
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "invalid torrent") {
+		t.Errorf("invalid torrent: error %v", results[1].Err)
+	}
+	if n := len(f.called("core.add_torrent_files")); n != 0 {
+		t.Errorf("nothing valid to add, but core.add_torrent_files was called")
+	}
+}
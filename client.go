@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
+	"sync"
 	"time"
 
 	"golang.org/x/net/publicsuffix"
@@ -18,6 +19,18 @@ type Client struct {
 	token      string
 	user_agent *http.Client
 	index      int
+	mu         sync.Mutex
+	seedTime   *SeedTimeLimiter
+
+	pluginMu sync.Mutex
+	plugins  []string
+
+	methodMu      sync.Mutex
+	methods       map[string]bool
+	methodsFailed time.Time
+
+	versionMu sync.Mutex
+	version   DaemonVersion
 }
 
 func (c *Client) setToken() error {
@@ -28,7 +41,7 @@ func (c *Client) setToken() error {
 		return err
 	}
 	if res.Error.Code != 0 {
-		return fmt.Errorf("Error pausing torrent: %s", res.Error.Message)
+		return fmt.Errorf("Error logging in: %s", res.Error.Message)
 	}
 
 	return nil
@@ -58,5 +71,9 @@ func NewClient(c *Client) (*Client, error) {
 		return nil, err
 	}
 
+	//Not fatal: deluge-web may not be connected to a daemon yet, in which
+	//case version dependent methods try both forms
+	c.DetectVersion()
+
 	return c, nil
 }
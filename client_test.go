@@ -0,0 +1,106 @@
+package deluge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// fakeCall is a request received by fakeDeluge
+type fakeCall struct {
+	Method string
+	Params []json.RawMessage
+}
+
+// fakeDeluge is a deluge-web JSON-RPC endpoint answering from handlers. A
+// handler's result is sent as the RPC result, or as the RPC error if it is an
+// RpcError. auth.login and daemon.get_method_list are answered unless a
+// handler overrides them; the method list holds every handled method.
+type fakeDeluge struct {
+	mu       sync.Mutex
+	handlers map[string]func(params []json.RawMessage) interface{}
+	calls    []fakeCall
+}
+
+func newFakeDeluge(version string) *fakeDeluge {
+	return &fakeDeluge{handlers: map[string]func([]json.RawMessage) interface{}{
+		"daemon.info":                 func([]json.RawMessage) interface{} { return version },
+		"core.get_libtorrent_version": func([]json.RawMessage) interface{} { return "1.2.0.0" },
+	}}
+}
+
+func (f *fakeDeluge) handle(method string, handler func(params []json.RawMessage) interface{}) {
+	f.mu.Lock()
+	f.handlers[method] = handler
+	f.mu.Unlock()
+}
+
+// called returns the requests made for method
+func (f *fakeDeluge) called(method string) []fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []fakeCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (f *fakeDeluge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{Method: req.Method, Params: req.Params})
+	handler, ok := f.handlers[req.Method]
+	var methods []string
+	for method := range f.handlers {
+		methods = append(methods, method)
+	}
+	f.mu.Unlock()
+
+	var result interface{}
+	switch {
+	case ok:
+		result = handler(req.Params)
+	case req.Method == "auth.login":
+		result = true
+	case req.Method == "daemon.get_method_list":
+		sort.Strings(methods)
+		result = methods
+	default:
+		result = RpcError{Message: "Unknown method", Code: 2}
+	}
+
+	res := map[string]interface{}{"id": req.ID, "result": result, "error": nil}
+	if e, ok := result.(RpcError); ok {
+		res["result"] = nil
+		res["error"] = e
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// newTestClient returns a client logged in to f
+func newTestClient(t *testing.T, f *fakeDeluge) *Client {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(&Client{API: srv.URL, Password: "deluge"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
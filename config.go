@@ -0,0 +1,223 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ProxyConfig is the Deluge 2 proxy setting
+type ProxyConfig struct {
+	// Type is 0 for none, 1 Socks4, 2 Socks5, 3 Socks5 with auth, 4 HTTP,
+	// 5 HTTP with auth and 6 I2P
+	Type                    int    `json:"type"`
+	Hostname                string `json:"hostname"`
+	Port                    int    `json:"port"`
+	Username                string `json:"username"`
+	Password                string `json:"password"`
+	ProxyHostnames          bool   `json:"proxy_hostnames"`
+	ProxyPeerConnections    bool   `json:"proxy_peer_connections"`
+	ProxyTrackerConnections bool   `json:"proxy_tracker_connections"`
+	ForceProxy              bool   `json:"force_proxy"`
+	AnonymousMode           bool   `json:"anonymous_mode"`
+}
+
+// CoreConfig holds the commonly used daemon settings. Keys without a field
+// are available in Raw. GetConfig fills in every field the daemon knows;
+// SetConfig ignores nil fields and only sends the others when they differ
+// from the daemon's current values, so a CoreConfig listing just the
+// settings to manage can be passed to it directly.
+type CoreConfig struct {
+	DownloadLocation  *string `json:"download_location,omitempty"`
+	MoveCompleted     *bool   `json:"move_completed,omitempty"`
+	MoveCompletedPath *string `json:"move_completed_path,omitempty"`
+	AddPaused         *bool   `json:"add_paused,omitempty"`
+
+	// Queue limits; -1 is unlimited
+	MaxActiveLimit       *int `json:"max_active_limit,omitempty"`
+	MaxActiveDownloading *int `json:"max_active_downloading,omitempty"`
+	MaxActiveSeeding     *int `json:"max_active_seeding,omitempty"`
+
+	// Global rate limits in KiB/s and connection limits; -1 is unlimited
+	MaxDownloadSpeed        *float64 `json:"max_download_speed,omitempty"`
+	MaxUploadSpeed          *float64 `json:"max_upload_speed,omitempty"`
+	MaxConnectionsGlobal    *int     `json:"max_connections_global,omitempty"`
+	MaxUploadSlotsGlobal    *int     `json:"max_upload_slots_global,omitempty"`
+	MaxHalfOpenConnections  *int     `json:"max_half_open_connections,omitempty"`
+	MaxConnectionsPerSecond *int     `json:"max_connections_per_second,omitempty"`
+
+	// ListenPorts is the first and last port of the listen range
+	ListenPorts     []int   `json:"listen_ports,omitempty"`
+	RandomPort      *bool   `json:"random_port,omitempty"`
+	ListenInterface *string `json:"listen_interface,omitempty"`
+
+	// Encryption policies are 0 forced, 1 enabled and 2 disabled; the level
+	// is 0 handshake, 1 full stream and 2 either
+	EncInPolicy  *int `json:"enc_in_policy,omitempty"`
+	EncOutPolicy *int `json:"enc_out_policy,omitempty"`
+	EncLevel     *int `json:"enc_level,omitempty"`
+
+	// Proxy is nil on Deluge 1.3, which uses the "proxies" key in Raw
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	QueueNewToTop         *bool    `json:"queue_new_to_top,omitempty"`
+	DontCountSlowTorrents *bool    `json:"dont_count_slow_torrents,omitempty"`
+	StopSeedAtRatio       *bool    `json:"stop_seed_at_ratio,omitempty"`
+	StopSeedRatio         *float64 `json:"stop_seed_ratio,omitempty"`
+	RemoveSeedAtRatio     *bool    `json:"remove_seed_at_ratio,omitempty"`
+	ShareRatioLimit       *float64 `json:"share_ratio_limit,omitempty"`
+	SeedTimeRatioLimit    *float64 `json:"seed_time_ratio_limit,omitempty"`
+	SeedTimeLimit         *int     `json:"seed_time_limit,omitempty"`
+
+	DHT    *bool `json:"dht,omitempty"`
+	UPnP   *bool `json:"upnp,omitempty"`
+	NATPMP *bool `json:"natpmp,omitempty"`
+	LSD    *bool `json:"lsd,omitempty"`
+	UTPEX  *bool `json:"utpex,omitempty"`
+
+	// CacheSize is in 16 KiB blocks, CacheExpiry in seconds
+	CacheSize   *int `json:"cache_size,omitempty"`
+	CacheExpiry *int `json:"cache_expiry,omitempty"`
+
+	// Raw holds every key returned by the daemon
+	Raw map[string]interface{} `json:"-"`
+}
+
+// GetRawConfig returns every daemon setting as returned by core.get_config
+func (c *Client) GetRawConfig() (map[string]interface{}, error) {
+	var res struct {
+		Result map[string]interface{} `json:"result"`
+		Error  RpcError               `json:"error"`
+	}
+	err := c.action("core.get_config", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting config: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetConfig returns the daemon settings
+func (c *Client) GetConfig() (CoreConfig, error) {
+	raw, err := c.GetRawConfig()
+	if err != nil {
+		return CoreConfig{}, err
+	}
+
+	var cfg CoreConfig
+	b, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(b, &cfg)
+	}
+	if err != nil {
+		return CoreConfig{}, fmt.Errorf("Error getting config: %w", err)
+	}
+	cfg.Raw = raw
+
+	return cfg, nil
+}
+
+// GetConfigValue returns a single daemon setting
+func (c *Client) GetConfigValue(key string) (interface{}, error) {
+	p, err := params(key)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting config value: %w", err)
+	}
+
+	var res struct {
+		Result interface{} `json:"result"`
+		Error  RpcError    `json:"error"`
+	}
+	err = c.action("core.get_config_value", p, &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting config value: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting config value: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// SetRawConfig sends the given settings to core.set_config as is
+func (c *Client) SetRawConfig(config map[string]interface{}) error {
+	if len(config) == 0 {
+		return nil
+	}
+
+	p, err := params(config)
+	if err != nil {
+		return fmt.Errorf("Error setting config: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("core.set_config", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error setting config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error setting config: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// SetConfig sends the non-nil fields of cfg that differ from the daemon's
+// current settings and returns the keys that were changed. Fields the daemon
+// does not know are skipped.
+func (c *Client) SetConfig(cfg CoreConfig) ([]string, error) {
+	current, err := c.GetRawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var desired map[string]interface{}
+	b, err := json.Marshal(cfg)
+	if err == nil {
+		err = json.Unmarshal(b, &desired)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error setting config: %w", err)
+	}
+
+	changes := diffConfig(current, desired)
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+
+	return keys, c.SetRawConfig(changes)
+}
+
+// diffConfig returns the entries of desired whose value differs from current,
+// ignoring keys current does not have
+func diffConfig(current map[string]interface{}, desired map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for key, value := range desired {
+		old, ok := current[key]
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(normalize(old), normalize(value)) {
+			changes[key] = value
+		}
+	}
+	return changes
+}
+
+// normalize round trips a value through JSON so values decoded from the
+// daemon and built in Go compare equal
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if json.Unmarshal(b, &out) != nil {
+		return v
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package deluge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSetConfig(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_config", func([]json.RawMessage) interface{} {
+		return map[string]interface{}{
+			"download_location": "/downloads",
+			"dht":               true,
+			"listen_ports":      []int{6881, 6891},
+			"max_active_limit":  8,
+			"random_port":       false,
+		}
+	})
+	f.handle("core.set_config", func([]json.RawMessage) interface{} { return nil })
+	c := newTestClient(t, f)
+
+	location := "/x"
+	tests := []struct {
+		name string
+		cfg  CoreConfig
+		sent map[string]interface{}
+	}{
+		{"unset fields", CoreConfig{DownloadLocation: &location}, map[string]interface{}{"download_location": "/x"}},
+		{"zero values", CoreConfig{DHT: Bool(false), MaxActiveLimit: Int(0)}, map[string]interface{}{"dht": false, "max_active_limit": float64(0)}},
+		{"unchanged", CoreConfig{DHT: Bool(true), ListenPorts: []int{6881, 6891}}, nil},
+		{"unknown to the daemon", CoreConfig{UPnP: Bool(true)}, nil},
+		{"empty", CoreConfig{}, nil},
+	}
+
+	for _, tt := range tests {
+		before := len(f.called("core.set_config"))
+		keys, err := c.SetConfig(tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(keys) != len(tt.sent) {
+			t.Errorf("%s: changed %v, want keys of %v", tt.name, keys, tt.sent)
+		}
+
+		calls := f.called("core.set_config")[before:]
+		if tt.sent == nil {
+			if len(calls) != 0 {
+				t.Errorf("%s: unexpected core.set_config call", tt.name)
+			}
+			continue
+		}
+		if len(calls) != 1 {
+			t.Fatalf("%s: %d core.set_config calls, want 1", tt.name, len(calls))
+		}
+		var sent map[string]interface{}
+		if err := json.Unmarshal(calls[0].Params[0], &sent); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sent, tt.sent) {
+			t.Errorf("%s: sent %v, want %v", tt.name, sent, tt.sent)
+		}
+	}
+
+	//A config read back and passed in unchanged must send nothing
+	before := len(f.called("core.set_config"))
+	cfg, err := c.GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DownloadLocation == nil || *cfg.DownloadLocation != "/downloads" || cfg.UPnP != nil {
+		t.Errorf("GetConfig = %+v", cfg)
+	}
+	if keys, err := c.SetConfig(cfg); err != nil || len(keys) != 0 {
+		t.Errorf("SetConfig(GetConfig()) = %v, %v", keys, err)
+	}
+	if len(f.called("core.set_config")) != before {
+		t.Errorf("SetConfig(GetConfig()) called core.set_config")
+	}
+}
@@ -0,0 +1,90 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/naposproject/go-deluge/metainfo"
+)
+
+// RemoteTorrentOptions configures CreateRemoteTorrent. Paths are on the
+// daemon's filesystem.
+type RemoteTorrentOptions struct {
+	// Path is the file or directory to create the torrent from
+	Path string
+	// Target is where the daemon writes the .torrent
+	Target string
+	// PieceLength is the piece size in bytes, as metafile.make_meta_file
+	// takes it: a power of two of at least 16 KiB, e.g. 256 << 10
+	PieceLength int
+	Trackers    []string
+	WebSeeds    []string
+	Private     bool
+	Comment     string
+	CreatedBy   string
+	// AddToSession adds the new torrent to the daemon once created
+	AddToSession bool
+}
+
+// CreateAndSeed creates a .torrent for the file or directory at path and adds
+// it to the daemon in seed mode, returning its info hash. The daemon must see
+// the content at the same path, so copts.Name cannot rename it. opts may be
+// nil.
+func (c *Client) CreateAndSeed(path string, copts metainfo.CreateOptions, opts *AddOptions) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("Error creating torrent: %w", err)
+	}
+	//Seed mode skips the check that would notice the data is not at
+	//DownloadLocation/Name
+	if copts.Name != "" && copts.Name != filepath.Base(abs) {
+		return "", fmt.Errorf("Error creating torrent: name %q differs from %q, the daemon would not find the data", copts.Name, filepath.Base(abs))
+	}
+	blob, err := metainfo.Create(abs, copts)
+	if err != nil {
+		return "", fmt.Errorf("Error creating torrent: %w", err)
+	}
+
+	o := opts.options()
+	o.SeedMode = Bool(true)
+	o.DownloadLocation = filepath.Dir(abs)
+
+	return c.AddTorrentBytes(filepath.Base(abs)+".torrent", blob, &o)
+}
+
+// CreateRemoteTorrent has the daemon create a .torrent via core.create_torrent
+func (c *Client) CreateRemoteTorrent(opts RemoteTorrentOptions) error {
+	if opts.PieceLength < 16<<10 || opts.PieceLength&(opts.PieceLength-1) != 0 {
+		return fmt.Errorf("Error creating torrent: piece length %d must be a power of two of at least 16 KiB, in bytes", opts.PieceLength)
+	}
+
+	tracker := ""
+	if len(opts.Trackers) > 0 {
+		tracker = opts.Trackers[0]
+	}
+	trackers := make([][]string, len(opts.Trackers))
+	for i, t := range opts.Trackers {
+		trackers[i] = []string{t}
+	}
+
+	p, err := params(opts.Path, tracker, opts.PieceLength, opts.Comment, opts.Target,
+		opts.WebSeeds, opts.Private, opts.CreatedBy, trackers, opts.AddToSession)
+	if err != nil {
+		return fmt.Errorf("Error creating torrent: %w", err)
+	}
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  RpcError        `json:"error"`
+	}
+	err = c.action("core.create_torrent", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error creating torrent: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error creating torrent: %s", res.Error.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package deluge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naposproject/go-deluge/metainfo"
+)
+
+func TestCreateAndSeed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	if err := ioutil.WriteFile(path, []byte("some data to seed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.add_torrent_file", func(params []json.RawMessage) interface{} { return addedHash(params) })
+	c := newTestClient(t, f)
+
+	for _, name := range []string{"", "data.bin"} {
+		hash, err := c.CreateAndSeed(path, metainfo.CreateOptions{Name: name}, nil)
+		if err != nil || len(hash) != 40 {
+			t.Fatalf("name %q: CreateAndSeed = %q, %v", name, hash, err)
+		}
+	}
+	calls := f.called("core.add_torrent_file")
+	if len(calls) != 2 {
+		t.Fatalf("core.add_torrent_file called %d times, want 2", len(calls))
+	}
+	var opts map[string]interface{}
+	json.Unmarshal(calls[0].Params[2], &opts)
+	if opts["seed_mode"] != true || opts["download_location"] != dir {
+		t.Errorf("add options = %v", opts)
+	}
+
+	_, err := c.CreateAndSeed(path, metainfo.CreateOptions{Name: "renamed"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "would not find the data") {
+		t.Errorf("renamed: error %v", err)
+	}
+	if n := len(f.called("core.add_torrent_file")); n != 2 {
+		t.Errorf("renamed torrent was added")
+	}
+}
+
+func TestCreateRemoteTorrent(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.create_torrent", func([]json.RawMessage) interface{} { return nil })
+	c := newTestClient(t, f)
+
+	for _, n := range []int{0, 256, 100 << 10} {
+		err := c.CreateRemoteTorrent(RemoteTorrentOptions{Path: "/data", Target: "/data.torrent", PieceLength: n})
+		if err == nil || !strings.Contains(err.Error(), "power of two") {
+			t.Errorf("PieceLength %d: error %v", n, err)
+		}
+	}
+	if n := len(f.called("core.create_torrent")); n != 0 {
+		t.Errorf("invalid piece lengths were sent")
+	}
+
+	err := c.CreateRemoteTorrent(RemoteTorrentOptions{Path: "/data", Target: "/data.torrent", PieceLength: 256 << 10,
+		Trackers: []string{"http://a/announce", "http://b/announce"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := f.called("core.create_torrent")[0].Params
+	var tracker string
+	var pieceLength int
+	var trackers [][]string
+	json.Unmarshal(params[1], &tracker)
+	json.Unmarshal(params[2], &pieceLength)
+	json.Unmarshal(params[8], &trackers)
+	if tracker != "http://a/announce" || pieceLength != 256<<10 || len(trackers) != 2 || trackers[1][0] != "http://b/announce" {
+		t.Errorf("core.create_torrent params = %s", params)
+	}
+}
@@ -0,0 +1,375 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DesiredState is the configuration ApplyConfig converges a daemon to.
+// Anything left out is not touched.
+type DesiredState struct {
+	// Core holds core.set_config keys and their values
+	Core map[string]interface{} `json:"core" yaml:"core" toml:"core"`
+	// Plugins maps plugin names to whether they should be enabled
+	Plugins map[string]bool `json:"plugins" yaml:"plugins" toml:"plugins"`
+	// Labels maps label names to LabelOptions keys (e.g. "max_upload_speed")
+	// and their values; unlisted options keep their current value
+	Labels map[string]map[string]interface{} `json:"labels" yaml:"labels" toml:"labels"`
+	// PruneLabels removes labels that are not listed in Labels
+	PruneLabels bool `json:"prune_labels" yaml:"prune_labels" toml:"prune_labels"`
+	// Hosts are the daemons deluge-web should know, matched on host, port
+	// and username (host and port only on Deluge 1.3)
+	Hosts []Host `json:"hosts" yaml:"hosts" toml:"hosts"`
+	// PruneHosts removes hosts that are not listed in Hosts
+	PruneHosts bool `json:"prune_hosts" yaml:"prune_hosts" toml:"prune_hosts"`
+}
+
+// Change is a single step of a Plan
+type Change struct {
+	// Kind is "plugin", "core", "label" or "host"
+	Kind string
+	// Action is "enable", "disable", "set", "add" or "remove"
+	Action string
+	// Target is the plugin, label or host concerned; empty for core
+	Target string
+	// Key is the setting changed by a "set"
+	Key  string
+	From interface{}
+	To   interface{}
+}
+
+func (ch Change) String() string {
+	target := ch.Kind
+	if ch.Target != "" {
+		target += " " + ch.Target
+	}
+	if ch.Action == "set" {
+		return fmt.Sprintf("set %s %s: %v -> %v", target, ch.Key, ch.From, ch.To)
+	}
+	return fmt.Sprintf("%s %s", ch.Action, target)
+}
+
+// Plan is the ordered list of changes needed to reach a DesiredState
+type Plan []Change
+
+func (p Plan) String() string {
+	lines := make([]string, len(p))
+	for i, ch := range p {
+		lines[i] = ch.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LoadDesiredState reads a DesiredState from a .json, .yaml, .yml or .toml
+// file
+func LoadDesiredState(path string) (DesiredState, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return DesiredState{}, fmt.Errorf("Error loading desired state: %w", err)
+	}
+	return ParseDesiredState(b, strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// ParseDesiredState decodes a DesiredState in the given format: "json",
+// "yaml", "yml" or "toml"
+func ParseDesiredState(b []byte, format string) (DesiredState, error) {
+	var state DesiredState
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		err = json.Unmarshal(b, &state)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(b, &state)
+	case "toml":
+		err = toml.Unmarshal(b, &state)
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return DesiredState{}, fmt.Errorf("Error loading desired state: %w", err)
+	}
+	return state, nil
+}
+
+// PlanConfig compares the daemon against desired and returns the changes
+// ApplyConfig would make, without making them
+func (c *Client) PlanConfig(desired DesiredState) (Plan, error) {
+	var plan Plan
+
+	enabled, err := c.RefreshPlugins()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(desired.Plugins) {
+		want := desired.Plugins[name]
+		if want && !contains(enabled, name) {
+			plan = append(plan, Change{Kind: "plugin", Action: "enable", Target: name})
+		} else if !want && contains(enabled, name) {
+			plan = append(plan, Change{Kind: "plugin", Action: "disable", Target: name})
+		}
+	}
+
+	if len(desired.Core) > 0 {
+		current, err := c.GetRawConfig()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(desired.Core) {
+			old, ok := current[key]
+			if !ok {
+				return nil, fmt.Errorf("Error planning config: unknown core setting %q", key)
+			}
+			value := normalize(desired.Core[key])
+			if !reflect.DeepEqual(normalize(old), value) {
+				plan = append(plan, Change{Kind: "core", Action: "set", Key: key, From: old, To: value})
+			}
+		}
+	}
+
+	if len(desired.Labels) > 0 || desired.PruneLabels {
+		changes, err := c.planLabels(desired, contains(enabled, "Label"))
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, changes...)
+	}
+
+	if len(desired.Hosts) > 0 || desired.PruneHosts {
+		changes, err := c.planHosts(desired)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, changes...)
+	}
+
+	return plan, nil
+}
+
+// planLabels diffs the labels and their options. When the Label plugin is not
+// enabled yet every label is treated as missing.
+func (c *Client) planLabels(desired DesiredState, enabled bool) (Plan, error) {
+	var plan Plan
+
+	var existing []string
+	if enabled {
+		var err error
+		existing, err = c.labelNames()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sortedKeys(desired.Labels) {
+		label := strings.ToLower(name)
+		err := ValidateLabel(label)
+		if err != nil {
+			return nil, err
+		}
+
+		current := map[string]interface{}{}
+		if contains(existing, label) {
+			opts, err := c.GetLabelOptions(label)
+			if err != nil {
+				return nil, err
+			}
+			current = normalize(opts).(map[string]interface{})
+		} else {
+			plan = append(plan, Change{Kind: "label", Action: "add", Target: label})
+			current = normalize(defaultLabelOptions).(map[string]interface{})
+		}
+
+		options := desired.Labels[name]
+		for _, key := range sortedKeys(options) {
+			old, ok := current[key]
+			if !ok {
+				return nil, fmt.Errorf("Error planning config: unknown label option %q", key)
+			}
+			value := normalize(options[key])
+			if !reflect.DeepEqual(old, value) {
+				plan = append(plan, Change{Kind: "label", Action: "set", Target: label, Key: key, From: old, To: value})
+			}
+		}
+	}
+
+	if desired.PruneLabels {
+		wanted := map[string]bool{}
+		for name := range desired.Labels {
+			wanted[strings.ToLower(name)] = true
+		}
+		for _, label := range existing {
+			if !wanted[label] {
+				plan = append(plan, Change{Kind: "label", Action: "remove", Target: label})
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// planHosts diffs the hosts known to deluge-web
+func (c *Client) planHosts(desired DesiredState) (Plan, error) {
+	var plan Plan
+
+	hosts, err := c.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, want := range desired.Hosts {
+		found := false
+		for _, h := range hosts {
+			if sameHost(h, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			plan = append(plan, Change{Kind: "host", Action: "add", Target: hostName(want), To: want})
+		}
+	}
+
+	if desired.PruneHosts {
+		for _, h := range hosts {
+			found := false
+			for _, want := range desired.Hosts {
+				if sameHost(h, want) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				plan = append(plan, Change{Kind: "host", Action: "remove", Target: hostName(h), From: h})
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyConfig converges the daemon to desired and returns the changes made,
+// which on error are the ones applied before it. Plugins are handled first so
+// labels can be configured in the same run; applying the same state again
+// makes no changes.
+func (c *Client) ApplyConfig(desired DesiredState) (Plan, error) {
+	plan, err := c.PlanConfig(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied Plan
+	for i := 0; i < len(plan); {
+		ch := plan[i]
+
+		//Consecutive core settings, and a label's options, are sent together
+		end := i + 1
+		if ch.Action == "set" {
+			for end < len(plan) && plan[end].Kind == ch.Kind && plan[end].Action == "set" && plan[end].Target == ch.Target {
+				end++
+			}
+		}
+		changes := plan[i:end]
+
+		switch {
+		case ch.Kind == "plugin" && ch.Action == "enable":
+			err = c.EnsurePlugin(ch.Target, 30*time.Second)
+		case ch.Kind == "plugin" && ch.Action == "disable":
+			err = c.DisablePlugin(ch.Target)
+		case ch.Kind == "core":
+			err = c.SetRawConfig(changes.values())
+		case ch.Kind == "label" && ch.Action == "add":
+			err = c.AddLabel(ch.Target)
+		case ch.Kind == "label" && ch.Action == "remove":
+			err = c.RemoveLabel(ch.Target)
+		case ch.Kind == "label" && ch.Action == "set":
+			err = c.updateLabelOptions(ch.Target, changes.values())
+		case ch.Kind == "host" && ch.Action == "add":
+			_, err = c.AddHost(ch.To.(Host))
+		case ch.Kind == "host" && ch.Action == "remove":
+			err = c.RemoveHost(ch.From.(Host).ID)
+		}
+		if err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, changes...)
+		i = end
+	}
+
+	return applied, nil
+}
+
+// values maps the keys of "set" changes to their new values
+func (p Plan) values() map[string]interface{} {
+	values := make(map[string]interface{}, len(p))
+	for _, ch := range p {
+		values[ch.Key] = ch.To
+	}
+	return values
+}
+
+// updateLabelOptions merges changes into the label's current options
+func (c *Client) updateLabelOptions(label string, changes map[string]interface{}) error {
+	opts, err := c.GetLabelOptions(label)
+	if err != nil {
+		return err
+	}
+
+	merged := normalize(opts).(map[string]interface{})
+	for key, value := range changes {
+		merged[key] = value
+	}
+	b, err := json.Marshal(merged)
+	if err == nil {
+		err = json.Unmarshal(b, &opts)
+	}
+	if err != nil {
+		return fmt.Errorf("Error setting torrent label options: %w", err)
+	}
+
+	return c.SetLabelOptions(label, opts)
+}
+
+// defaultLabelOptions are the options the Label plugin gives a new label
+var defaultLabelOptions = LabelOptions{
+	MaxDownloadSpeed: -1,
+	MaxUploadSpeed:   -1,
+	MaxConnections:   -1,
+	MaxUploadSlots:   -1,
+	StopRatio:        2.0,
+	AutoAddTrackers:  []string{},
+}
+
+// sameHost compares hosts on host, port and, when both are known, username
+func sameHost(a Host, b Host) bool {
+	if a.Host != b.Host || a.Port != b.Port {
+		return false
+	}
+	return a.noUsername || b.noUsername || a.Username == b.Username
+}
+
+func hostName(h Host) string {
+	if h.Username != "" {
+		return fmt.Sprintf("%s@%s:%d", h.Username, h.Host, h.Port)
+	}
+	return fmt.Sprintf("%s:%d", h.Host, h.Port)
+}
+
+// sortedKeys returns the keys of a string keyed map in order
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,217 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHosts serves web.get_hosts, web.add_host and web.remove_host from a
+// host list; status, when set, is reported in place of the username as
+// Deluge 1.3 does
+func fakeHosts(f *fakeDeluge, status string, hosts [][]interface{}) {
+	var mu sync.Mutex
+	next := 0
+
+	f.handle("web.get_hosts", func([]json.RawMessage) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		result := make([][]interface{}, len(hosts))
+		for i, h := range hosts {
+			result[i] = append([]interface{}(nil), h...)
+			if status != "" {
+				result[i][3] = status
+			}
+		}
+		return result
+	})
+	f.handle("web.add_host", func(params []json.RawMessage) interface{} {
+		var host, username string
+		var port int
+		json.Unmarshal(params[0], &host)
+		json.Unmarshal(params[1], &port)
+		json.Unmarshal(params[2], &username)
+
+		mu.Lock()
+		defer mu.Unlock()
+		next++
+		id := fmt.Sprintf("added%d", next)
+		hosts = append(hosts, []interface{}{id, host, port, username})
+		if status != "" {
+			return []interface{}{true}
+		}
+		return []interface{}{true, id}
+	})
+	f.handle("web.remove_host", func(params []json.RawMessage) interface{} {
+		var id string
+		json.Unmarshal(params[0], &id)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, h := range hosts {
+			if h[0] == id {
+				hosts = append(hosts[:i], hosts[i+1:]...)
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestApplyConfigHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		status  string
+	}{
+		{"deluge 2", "2.1.1", ""},
+		{"deluge 1.3", "1.3.15", "Offline"},
+		{"deluge 1.3 connected", "1.3.15", "Connected"},
+		{"unknown version", "", "Offline"},
+	}
+
+	desired := DesiredState{
+		Hosts: []Host{
+			{Host: "127.0.0.1", Port: 58846, Username: "localclient"},
+			{Host: "10.0.0.2", Port: 58846, Username: "admin", Password: "secret"},
+		},
+		PruneHosts: true,
+	}
+
+	for _, tt := range tests {
+		f := newFakeDeluge(tt.version)
+		if tt.version == "" {
+			f.handle("daemon.info", func([]json.RawMessage) interface{} {
+				return RpcError{Message: "Not connected", Code: 1}
+			})
+		}
+		f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{} })
+		fakeHosts(f, tt.status, [][]interface{}{
+			{"local", "127.0.0.1", 58846, "localclient"},
+			{"old", "10.0.0.9", 58846, "admin"},
+		})
+		c := newTestClient(t, f)
+
+		plan, err := c.ApplyConfig(desired)
+		if err != nil {
+			t.Fatalf("%s: first run: %v", tt.name, err)
+		}
+		want := "add host admin@10.0.0.2:58846\nremove host 10.0.0.9:58846"
+		if tt.status == "" {
+			want = "add host admin@10.0.0.2:58846\nremove host admin@10.0.0.9:58846"
+		}
+		if plan.String() != want {
+			t.Errorf("%s: first run plan:\n%s\nwant:\n%s", tt.name, plan, want)
+		}
+
+		plan, err = c.ApplyConfig(desired)
+		if err != nil {
+			t.Fatalf("%s: second run: %v", tt.name, err)
+		}
+		if len(plan) != 0 {
+			t.Errorf("%s: second run plan:\n%s\nwant no changes", tt.name, plan)
+		}
+		if n := len(f.called("web.add_host")); n != 1 {
+			t.Errorf("%s: web.add_host called %d times, want 1", tt.name, n)
+		}
+		if n := len(f.called("web.remove_host")); n != 1 {
+			t.Errorf("%s: web.remove_host called %d times, want 1", tt.name, n)
+		}
+	}
+}
+
+func TestSameHost(t *testing.T) {
+	admin := Host{Host: "h", Port: 1, Username: "admin"}
+	tests := []struct {
+		a, b Host
+		want bool
+	}{
+		{admin, admin, true},
+		{admin, Host{Host: "h", Port: 1, Username: "other"}, false},
+		{admin, Host{Host: "h", Port: 2, Username: "admin"}, false},
+		{admin, Host{Host: "h", Port: 1, noUsername: true}, true},
+		{Host{Host: "h", Port: 1, noUsername: true}, admin, true},
+		{admin, Host{Host: "g", Port: 1, noUsername: true}, false},
+	}
+
+	for _, tt := range tests {
+		if got := sameHost(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameHost(%+v, %+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestApplyConfigPartial(t *testing.T) {
+	desired := DesiredState{
+		Core: map[string]interface{}{"a": 2, "b": 3},
+		Labels: map[string]map[string]interface{}{
+			"new": {"max_upload_speed": 100},
+			"tv":  {"max_connections": 50, "apply_max": true},
+		},
+		Hosts: []Host{{Host: "10.0.0.2", Port: 58846, Username: "admin"}},
+	}
+	all := []string{
+		"set core a: 1 -> 2",
+		"set core b: 1 -> 3",
+		"add label new",
+		"set label new max_upload_speed: -1 -> 100",
+		"set label tv apply_max: false -> true",
+		"set label tv max_connections: -1 -> 50",
+		"add host admin@10.0.0.2:58846",
+	}
+
+	tests := []struct {
+		fail    string
+		applied int
+	}{
+		{"", 7},
+		{"core.set_config", 0},
+		{"label.add", 2},
+		{"label.set_options", 3},
+		{"web.add_host", 6},
+	}
+
+	for _, tt := range tests {
+		f := newFakeDeluge("2.1.1")
+		ok := func(result interface{}) func([]json.RawMessage) interface{} {
+			return func([]json.RawMessage) interface{} { return result }
+		}
+		f.handle("core.get_enabled_plugins", ok([]string{"Label"}))
+		f.handle("core.get_config", ok(map[string]int{"a": 1, "b": 1}))
+		f.handle("core.set_config", ok(nil))
+		f.handle("label.get_labels", ok([]string{"tv"}))
+		f.handle("label.get_options", ok(normalize(defaultLabelOptions)))
+		f.handle("label.add", ok(nil))
+		f.handle("label.set_options", ok(true))
+		fakeHosts(f, "", nil)
+		if tt.fail != "" {
+			f.handle(tt.fail, ok(RpcError{Message: "Failed", Code: 1}))
+		}
+		c := newTestClient(t, f)
+
+		applied, err := c.ApplyConfig(desired)
+		if (err != nil) != (tt.fail != "") {
+			t.Errorf("fail %q: error %v", tt.fail, err)
+		}
+		want := strings.Join(all[:tt.applied], "\n")
+		if applied.String() != want {
+			t.Errorf("fail %q: applied:\n%s\nwant:\n%s", tt.fail, applied, want)
+		}
+	}
+
+	//Each label's options are sent in a single call
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Label"} })
+	f.handle("label.get_labels", func([]json.RawMessage) interface{} { return []string{"tv"} })
+	f.handle("label.get_options", func([]json.RawMessage) interface{} { return normalize(defaultLabelOptions) })
+	f.handle("label.set_options", func([]json.RawMessage) interface{} { return true })
+	c := newTestClient(t, f)
+	if _, err := c.ApplyConfig(DesiredState{Labels: map[string]map[string]interface{}{"tv": desired.Labels["tv"]}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.called("label.set_options")); n != 1 {
+		t.Errorf("label.set_options called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,268 @@
+package deluge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LowDiskSpace is the free space, in bytes, below which Diagnose reports a
+// save path as failing
+var LowDiskSpace int64 = 1 << 30
+
+// Finding is the outcome of a single Diagnose check
+type Finding struct {
+	Check  string
+	OK     bool
+	Detail string
+}
+
+// DiagnosticReport is the result of Diagnose
+type DiagnosticReport struct {
+	Findings []Finding
+}
+
+// OK reports whether every check passed
+func (r DiagnosticReport) OK() bool {
+	for _, f := range r.Findings {
+		if !f.OK {
+			return false
+		}
+	}
+	return true
+}
+
+func (r DiagnosticReport) String() string {
+	lines := make([]string, len(r.Findings))
+	for i, f := range r.Findings {
+		status := "ok"
+		if !f.OK {
+			status = "FAIL"
+		}
+		lines[i] = fmt.Sprintf("[%s] %s: %s", status, f.Check, f.Detail)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsDaemonConnected reports whether deluge-web is connected to a daemon
+func (c *Client) IsDaemonConnected() (bool, error) {
+	var res BoolResponse
+	err := c.action("web.connected", "", &res)
+	if err != nil {
+		return false, fmt.Errorf("Error checking daemon connection: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return false, fmt.Errorf("Error checking daemon connection: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetFreeSpace returns the free space, in bytes, at path on the daemon's
+// filesystem; an empty path checks the default download location
+func (c *Client) GetFreeSpace(path string) (int64, error) {
+	p, err := params(path)
+	if err != nil {
+		return 0, fmt.Errorf("Error getting free space: %w", err)
+	}
+
+	var res struct {
+		Result int64    `json:"result"`
+		Error  RpcError `json:"error"`
+	}
+	err = c.action("core.get_free_space", p, &res)
+	if err != nil {
+		return 0, fmt.Errorf("Error getting free space: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return 0, fmt.Errorf("Error getting free space: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// TestListenPort asks the daemon to check whether its listen port is
+// reachable from the internet
+func (c *Client) TestListenPort() (bool, error) {
+	var res BoolResponse
+	err := c.action("core.test_listen_port", "", &res)
+	if err != nil {
+		return false, fmt.Errorf("Error testing listen port: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return false, fmt.Errorf("Error testing listen port: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetListenPort returns the port the daemon accepts peer connections on
+func (c *Client) GetListenPort() (int, error) {
+	var res struct {
+		Result int      `json:"result"`
+		Error  RpcError `json:"error"`
+	}
+	err := c.action("core.get_listen_port", "", &res)
+	if err != nil {
+		return 0, fmt.Errorf("Error getting listen port: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return 0, fmt.Errorf("Error getting listen port: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetExternalIP returns the daemon's external IP address as seen by
+// libtorrent (Deluge 2 only)
+func (c *Client) GetExternalIP() (string, error) {
+	return c.stringResult("core.get_external_ip", "Error getting external IP")
+}
+
+// GetLibtorrentVersion returns the version of libtorrent the daemon runs
+func (c *Client) GetLibtorrentVersion() (string, error) {
+	return c.stringResult("core.get_libtorrent_version", "Error getting libtorrent version")
+}
+
+// GetDaemonInfo returns the Deluge version of the daemon
+func (c *Client) GetDaemonInfo() (string, error) {
+	return c.stringResult("daemon.info", "Error getting daemon info")
+}
+
+// stringResult calls a method without parameters that returns a string
+func (c *Client) stringResult(method string, context string) (string, error) {
+	var res StringResponse
+	err := c.action(method, "", &res)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", context, err)
+	}
+	if res.Error.Code != 0 {
+		return "", fmt.Errorf("%s: %s", context, res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// savePaths returns the default download location and every distinct save
+// path in use by a torrent
+func (c *Client) savePaths() ([]string, error) {
+	paths := map[string]bool{}
+
+	location, err := c.GetConfigValue("download_location")
+	if err != nil {
+		return nil, err
+	}
+	if location, ok := location.(string); ok && location != "" {
+		paths[location] = true
+	}
+
+	key := c.savePathKey()
+	p, err := params(map[string]interface{}{}, []string{key})
+	if err != nil {
+		return nil, fmt.Errorf("Error getting torrents: %w", err)
+	}
+	var res struct {
+		Result map[string]map[string]interface{} `json:"result"`
+		Error  RpcError                          `json:"error"`
+	}
+	err = c.action("core.get_torrents_status", p, &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting torrents: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting torrents: %s", res.Error.Message)
+	}
+	for _, torrent := range res.Result {
+		if path, ok := torrent[key].(string); ok && path != "" {
+			paths[path] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// Diagnose runs the checks support usually asks for: authentication, daemon
+// connection, versions, plugins, listen port reachability and free space on
+// every save path. Checks that depend on a failed one are skipped.
+func (c *Client) Diagnose() DiagnosticReport {
+	var r DiagnosticReport
+	add := func(check string, ok bool, detail string) {
+		r.Findings = append(r.Findings, Finding{Check: check, OK: ok, Detail: detail})
+	}
+
+	var session BoolResponse
+	err := c.action("auth.check_session", "", &session)
+	if err == nil && session.Error.Code != 0 {
+		err = fmt.Errorf("%s", session.Error.Message)
+	}
+	if err != nil || !session.Result {
+		detail := "session is not authenticated"
+		if err != nil {
+			detail = err.Error()
+		}
+		add("auth", false, detail)
+		return r
+	}
+	add("auth", true, "session is authenticated")
+
+	connected, err := c.IsDaemonConnected()
+	if err != nil || !connected {
+		detail := "deluge-web is not connected to a daemon"
+		if err != nil {
+			detail = err.Error()
+		}
+		add("daemon", false, detail)
+		return r
+	}
+	add("daemon", true, "deluge-web is connected to a daemon")
+
+	version, err := c.DetectVersion()
+	if err != nil {
+		add("version", false, err.Error())
+	} else {
+		add("version", true, version.String())
+	}
+
+	plugins, err := c.RefreshPlugins()
+	if err != nil {
+		add("plugins", false, err.Error())
+	} else {
+		add("plugins", true, "enabled: "+strings.Join(plugins, ", "))
+	}
+
+	port, err := c.GetListenPort()
+	if err != nil {
+		add("port", false, err.Error())
+	} else {
+		open, err := c.TestListenPort()
+		switch {
+		case err != nil:
+			add("port", false, fmt.Sprintf("port %d: %s", port, err.Error()))
+		case !open:
+			add("port", false, fmt.Sprintf("port %d is not reachable from the internet", port))
+		default:
+			add("port", true, fmt.Sprintf("port %d is reachable", port))
+		}
+	}
+
+	paths, err := c.savePaths()
+	if err != nil {
+		add("free space", false, err.Error())
+		return r
+	}
+	for _, path := range paths {
+		free, err := c.GetFreeSpace(path)
+		if err != nil {
+			add("free space "+path, false, err.Error())
+			continue
+		}
+		add("free space "+path, free >= LowDiskSpace, fmt.Sprintf("%d bytes free", free))
+	}
+
+	return r
+}
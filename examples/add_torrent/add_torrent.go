@@ -19,18 +19,20 @@ func main() {
 	}
 
 	fmt.Printf("Adding torrent via URL..\n")
-	err = c.AddTorrent("http://releases.ubuntu.com/18.04/ubuntu-18.04.1-desktop-amd64.iso.torrent")
+	hash, err := c.AddTorrent("http://releases.ubuntu.com/18.04/ubuntu-18.04.1-desktop-amd64.iso.torrent", nil)
 	if err != nil {
 		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
 	}
+	fmt.Printf("Added %s\n", hash)
 
 	fmt.Printf("Adding torrent via file..\n")
-	err = c.AddTorrentFile("/home/iceman/Downloads/ubuntu-18.04-desktop-amd64.iso.torrent")
-	if err != nil {
+	hash, err = c.AddTorrentFile("/home/iceman/Downloads/ubuntu-18.04-desktop-amd64.iso.torrent", nil)
+	if err != nil && err != deluge.ErrTorrentExists {
 		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
 	}
+	fmt.Printf("Added %s\n", hash)
 
 	os.Exit(0)
 }
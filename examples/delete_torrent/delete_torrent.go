@@ -20,16 +20,15 @@ func main() {
 	}
 
 	fmt.Printf("Adding torrent via URL..\n")
-	err = c.AddTorrent("http://releases.ubuntu.com/18.04/ubuntu-18.04.1-desktop-amd64.iso.torrent")
+	hash, err := c.AddTorrent("http://releases.ubuntu.com/18.04/ubuntu-18.04.1-desktop-amd64.iso.torrent",
+		&deluge.AddOptions{Wait: 10 * time.Second})
 	if err != nil {
 		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
 	}
 
-	time.Sleep(5 * time.Second)
-
 	fmt.Printf("Deleting torrent...\n")
-	err = c.RemoveTorrent("c3a41b13a4607f0b3188063aa5fb8a50e02ac4f5")
+	err = c.RemoveTorrent(hash)
 	if err != nil {
 		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
@@ -38,16 +37,15 @@ func main() {
 	time.Sleep(5 * time.Second)
 
 	fmt.Printf("ReAdding torrent via URL..\n")
-	err = c.AddTorrent("http://releases.ubuntu.com/18.04/ubuntu-18.04.1-desktop-amd64.iso.torrent")
+	hash, err = c.AddTorrent("http://releases.ubuntu.com/18.04/ubuntu-18.04.1-desktop-amd64.iso.torrent",
+		&deluge.AddOptions{Wait: 10 * time.Second})
 	if err != nil {
 		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
 	}
 
-	time.Sleep(10 * time.Second)
-
 	fmt.Printf("Deleting torrent and data...\n")
-	err = c.RemoveTorrentAndData("c3a41b13a4607f0b3188063aa5fb8a50e02ac4f5")
+	err = c.RemoveTorrentAndData(hash)
 	if err != nil {
 		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
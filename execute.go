@@ -0,0 +1,104 @@
+package deluge
+
+import (
+	"fmt"
+)
+
+// Events an Execute plugin command can run on
+const (
+	ExecuteOnAdded    = "added"
+	ExecuteOnComplete = "complete"
+	ExecuteOnRemoved  = "removed"
+)
+
+// ExecuteCommand is a command the Execute plugin runs when a torrent event
+// occurs; it is passed the torrent's id, name and save path
+type ExecuteCommand struct {
+	ID      string
+	Event   string
+	Command string
+}
+
+// GetExecuteCommands returns the Execute plugin commands
+func (c *Client) GetExecuteCommands() ([]ExecuteCommand, error) {
+	err := c.requirePlugin("Execute")
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Result [][]string `json:"result"`
+		Error  RpcError   `json:"error"`
+	}
+	err = c.action("execute.get_commands", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting execute commands: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting execute commands: %s", res.Error.Message)
+	}
+
+	//Each command is [id, event, command]
+	var commands []ExecuteCommand
+	for _, entry := range res.Result {
+		if len(entry) != 3 {
+			continue
+		}
+		commands = append(commands, ExecuteCommand{ID: entry[0], Event: entry[1], Command: entry[2]})
+	}
+
+	return commands, nil
+}
+
+// AddExecuteCommand adds a command run on event, one of ExecuteOnAdded,
+// ExecuteOnComplete or ExecuteOnRemoved
+func (c *Client) AddExecuteCommand(event string, command string) error {
+	err := c.requirePlugin("Execute")
+	if err != nil {
+		return err
+	}
+
+	if event != ExecuteOnAdded && event != ExecuteOnComplete && event != ExecuteOnRemoved {
+		return fmt.Errorf("Error adding execute command: unknown event %q", event)
+	}
+
+	p, err := params(event, command)
+	if err != nil {
+		return fmt.Errorf("Error adding execute command: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("execute.add_command", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error adding execute command: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error adding execute command: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// RemoveExecuteCommand removes the command with the given ID
+func (c *Client) RemoveExecuteCommand(id string) error {
+	err := c.requirePlugin("Execute")
+	if err != nil {
+		return err
+	}
+
+	p, err := params(id)
+	if err != nil {
+		return fmt.Errorf("Error removing execute command: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("execute.remove_command", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error removing execute command: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error removing execute command: %s", res.Error.Message)
+	}
+
+	return nil
+}
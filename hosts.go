@@ -0,0 +1,122 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Host is a daemon known to deluge-web's connection manager
+type Host struct {
+	ID   string `json:"-" yaml:"-" toml:"-"`
+	Host string `json:"host" yaml:"host" toml:"host"`
+	Port int    `json:"port" yaml:"port" toml:"port"`
+	// Username is empty in hosts read from Deluge 1.3, which does not report
+	// it
+	Username string `json:"username" yaml:"username" toml:"username"`
+	// Password is only used when adding a host; it is never read back
+	Password string `json:"password" yaml:"password" toml:"password"`
+
+	// noUsername is set when the daemon did not report the username, so it
+	// cannot be used to tell hosts apart
+	noUsername bool
+}
+
+// hostStatuses are the connection states Deluge 1.3 reports in place of the
+// username
+var hostStatuses = map[string]bool{"Offline": true, "Online": true, "Connected": true}
+
+// GetHosts returns the daemons configured in deluge-web
+func (c *Client) GetHosts() ([]Host, error) {
+	var res struct {
+		Result [][]interface{} `json:"result"`
+		Error  RpcError        `json:"error"`
+	}
+	err := c.action("web.get_hosts", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting hosts: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting hosts: %s", res.Error.Message)
+	}
+
+	version := c.Version()
+	var hosts []Host
+	for _, entry := range res.Result {
+		//Entries are [id, host, port, username] (Deluge 1.3 sends a status
+		//string in place of the username)
+		if len(entry) < 3 {
+			continue
+		}
+		var h Host
+		h.ID, _ = entry[0].(string)
+		h.Host, _ = entry[1].(string)
+		port, _ := entry[2].(float64)
+		h.Port = int(port)
+		if len(entry) > 3 {
+			h.Username, _ = entry[3].(string)
+		}
+		if len(entry) <= 3 || version.IsV1() || (!version.Known() && hostStatuses[h.Username]) {
+			h.Username = ""
+			h.noUsername = true
+		}
+		hosts = append(hosts, h)
+	}
+
+	return hosts, nil
+}
+
+// AddHost adds a daemon to deluge-web and returns its id, which is empty on
+// Deluge 1.3
+func (c *Client) AddHost(host Host) (string, error) {
+	p, err := params(host.Host, host.Port, host.Username, host.Password)
+	if err != nil {
+		return "", fmt.Errorf("Error adding host: %w", err)
+	}
+
+	var res struct {
+		Result []json.RawMessage `json:"result"`
+		Error  RpcError          `json:"error"`
+	}
+	err = c.action("web.add_host", p, &res)
+	if err != nil {
+		return "", fmt.Errorf("Error adding host: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return "", fmt.Errorf("Error adding host: %s", res.Error.Message)
+	}
+
+	//The result is [true, id] or [false, error message]; Deluge 1.3 sends
+	//just [true] on success
+	var ok bool
+	var detail string
+	if len(res.Result) > 0 {
+		json.Unmarshal(res.Result[0], &ok)
+	}
+	if len(res.Result) > 1 {
+		json.Unmarshal(res.Result[1], &detail)
+	}
+	if !ok {
+		return "", fmt.Errorf("Error adding host: %s", detail)
+	}
+
+	return detail, nil
+}
+
+// RemoveHost removes a daemon from deluge-web by id
+func (c *Client) RemoveHost(id string) error {
+	p, err := params(id)
+	if err != nil {
+		return fmt.Errorf("Error removing host: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("web.remove_host", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error removing host: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error removing host: %s", res.Error.Message)
+	}
+
+	return nil
+}
@@ -2,12 +2,15 @@ package deluge
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"strings"
 )
 
 // func (c *Client) url(path string) string {
@@ -21,12 +24,12 @@ import (
 // 	return fmt.Sprintf("%s%s", c.API, path)
 // }
 
-func (c *Client) request(method, path string, payload []byte, headers *http.Header) (*http.Response, error) {
+func (c *Client) request(ctx context.Context, method, path string, payload []byte, headers *http.Header) (*http.Response, error) {
 	if c == nil {
 		return nil, fmt.Errorf("Cannot make a request with a nil client")
 	}
 	in := bytes.NewBuffer(payload)
-	req, err := http.NewRequest(method, c.API, in)
+	req, err := http.NewRequestWithContext(ctx, method, c.API, in)
 	if err != nil {
 		return nil, err
 	}
@@ -47,20 +50,20 @@ func (c *Client) request(method, path string, payload []byte, headers *http.Head
 	return res, nil
 }
 
-func (c *Client) post(path string, payload []byte, headers *http.Header) (*http.Response, error) {
-	return c.request("POST", path, payload, headers)
+func (c *Client) post(ctx context.Context, path string, payload []byte, headers *http.Header) (*http.Response, error) {
+	return c.request(ctx, "POST", path, payload, headers)
 }
 
-func (c *Client) put(path string, payload []byte, headers *http.Header) (*http.Response, error) {
-	return c.request("PUT", path, payload, headers)
+func (c *Client) put(ctx context.Context, path string, payload []byte, headers *http.Header) (*http.Response, error) {
+	return c.request(ctx, "PUT", path, payload, headers)
 }
 
-func (c *Client) get(path string, headers *http.Header) (*http.Response, error) {
-	return c.request("GET", path, nil, headers)
+func (c *Client) get(ctx context.Context, path string, headers *http.Header) (*http.Response, error) {
+	return c.request(ctx, "GET", path, nil, headers)
 }
 
-func (c *Client) delete(path string, headers *http.Header) (*http.Response, error) {
-	return c.request("DELETE", path, nil, headers)
+func (c *Client) delete(ctx context.Context, path string, headers *http.Header) (*http.Response, error) {
+	return c.request(ctx, "DELETE", path, nil, headers)
 }
 
 // func (c *Client) action(action string, hash string, headers *http.Header) error {
@@ -75,34 +78,184 @@ func (c *Client) delete(path string, headers *http.Header) (*http.Response, erro
 // }
 
 func (c *Client) action(method string, params string, decoder interface{}) error {
+	return c.actionContext(context.Background(), method, params, decoder)
+}
+
+// actionContext calls method and decodes the response into decoder. A
+// request rejected because the session expired, e.g. after deluge-web
+// restarted, is retried once after logging in again.
+func (c *Client) actionContext(ctx context.Context, method string, params string, decoder interface{}) error {
+	err := c.checkMethod(ctx, method)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.rpc(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	if method != "auth.login" && notAuthenticated(body) {
+		err = c.setToken()
+		if err != nil {
+			return err
+		}
+		body, err = c.rpc(ctx, method, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = json.Unmarshal(body, &decoder)
+	if err != nil {
+		return errors.New("unable to parse response body: " + err.Error())
+	}
+
+	return nil
+}
+
+// rpc posts a single JSON-RPC request and returns the response body
+func (c *Client) rpc(ctx context.Context, method string, params string) ([]byte, error) {
+	c.mu.Lock()
 	var payload = fmt.Sprintf(`{"id":%d, "method":"%s", "params":[%s]}`, c.index, method, params)
 	c.index++
+	c.mu.Unlock()
 
 	header := make(http.Header)
 	header.Set("Content-Type", "application/json")
-	res, err := c.post("", []byte(payload), &header)
+	res, err := c.post(ctx, "", []byte(payload), &header)
 
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	//Deluge hangs if the action is invalid or hash doesnt match a torrent
 	if e, ok := err.(net.Error); ok && e.Timeout() {
-		return errors.New("request timed out. Check to make sure the action is valid for the speficied torrent")
+		return nil, errors.New("request timed out. Check to make sure the action is valid for the speficied torrent")
 	} else if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		return fmt.Errorf("error status: %s", res.Status)
+		return nil, fmt.Errorf("error status: %s", res.Status)
 	}
 
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return errors.New("unable to read response body")
+		return nil, errors.New("unable to read response body")
 	}
 
-	err = json.Unmarshal(body, &decoder)
+	return body, nil
+}
+
+// notAuthenticated reports whether deluge-web rejected a request because the
+// session is not logged in
+func notAuthenticated(body []byte) bool {
+	var res struct {
+		Error *RpcError `json:"error"`
+	}
+	return json.Unmarshal(body, &res) == nil && res.Error != nil && res.Error.Message == "Not authenticated"
+}
+
+// Call invokes any JSON-RPC method, such as one from a third party plugin,
+// with the given parameters and decodes its result into result, which may be
+// nil to discard it. An error reported by Deluge is returned as an RpcError
+// and a method the daemon does not have as ErrMethodUnavailable.
+func (c *Client) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	raw, err := c.CallRaw(ctx, method, params)
 	if err != nil {
-		return errors.New("unable to parse response body: " + err.Error())
+		return err
+	}
+	if result == nil {
+		return nil
 	}
 
+	err = json.Unmarshal(raw, result)
+	if err != nil {
+		return fmt.Errorf("Error calling %s: %w", method, err)
+	}
 	return nil
 }
+
+// CallRaw is Call returning the undecoded result
+func (c *Client) CallRaw(ctx context.Context, method string, args []interface{}) (json.RawMessage, error) {
+	p, err := params(args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error calling %s: %w", method, err)
+	}
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RpcError       `json:"error"`
+	}
+	err = c.actionContext(ctx, method, p, &res)
+	if e, ok := err.(ErrMethodUnavailable); ok {
+		return nil, e
+	} else if err != nil {
+		return nil, fmt.Errorf("Error calling %s: %w", method, err)
+	}
+	if res.Error != nil {
+		return nil, *res.Error
+	}
+
+	return res.Result, nil
+}
+
+// params encodes each value as JSON and joins them into a parameter list
+// suitable for action
+func params(values ...interface{}) (string, error) {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		encoded[i] = string(b)
+	}
+	return strings.Join(encoded, ","), nil
+}
+
+// upload posts a file to deluge-web's upload endpoint, next to the JSON API,
+// and returns the temporary path it was stored at on the web server
+func (c *Client) upload(filename string, data []byte) (string, error) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+	err = form.Close()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(c.API, "json")+"upload", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	res, err := c.user_agent.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("error status: %s", res.Status)
+	}
+
+	var uploaded struct {
+		Success bool     `json:"success"`
+		Files   []string `json:"files"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&uploaded)
+	if err != nil {
+		return "", errors.New("unable to parse response body: " + err.Error())
+	}
+	if !uploaded.Success || len(uploaded.Files) == 0 {
+		return "", errors.New("upload was rejected")
+	}
+
+	return uploaded.Files[0], nil
+}
@@ -0,0 +1,293 @@
+package deluge
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// labelPattern matches the label names accepted by the Label plugin
+var labelPattern = regexp.MustCompile(`^[a-z0-9_\-.]+$`)
+
+// Label is a Label plugin label and the number of torrents carrying it
+type Label struct {
+	Name  string
+	Count int
+}
+
+// LabelOptions are the settings the Label plugin applies to torrents with a
+// label
+type LabelOptions struct {
+	// ApplyMax applies the speed and connection limits below
+	ApplyMax bool `json:"apply_max"`
+	// Speeds are in KiB/s; -1 is unlimited
+	MaxDownloadSpeed float64 `json:"max_download_speed"`
+	MaxUploadSpeed   float64 `json:"max_upload_speed"`
+	// Connection limits; -1 is unlimited
+	MaxConnections      int  `json:"max_connections"`
+	MaxUploadSlots      int  `json:"max_upload_slots"`
+	PrioritizeFirstLast bool `json:"prioritize_first_last"`
+
+	// ApplyQueue applies the queue and ratio settings below
+	ApplyQueue    bool    `json:"apply_queue"`
+	AutoManaged   bool    `json:"is_auto_managed"`
+	StopAtRatio   bool    `json:"stop_at_ratio"`
+	StopRatio     float64 `json:"stop_ratio"`
+	RemoveAtRatio bool    `json:"remove_at_ratio"`
+
+	// ApplyMoveCompleted applies the move completed settings below
+	ApplyMoveCompleted bool   `json:"apply_move_completed"`
+	MoveCompleted      bool   `json:"move_completed"`
+	MoveCompletedPath  string `json:"move_completed_path"`
+
+	// AutoAdd labels new torrents announcing to one of AutoAddTrackers
+	AutoAdd         bool     `json:"auto_add"`
+	AutoAddTrackers []string `json:"auto_add_trackers"`
+}
+
+// LabelOptionsResponse is the result of label.get_options
+type LabelOptionsResponse struct {
+	Id     int          `json:"id"`
+	Result LabelOptions `json:"result"`
+	Error  RpcError     `json:"error"`
+}
+
+// ValidateLabel checks a label name against the Label plugin's rules: it
+// must not be empty and, once lowercased, may only contain a-z, 0-9, "_",
+// "-" and "."
+func ValidateLabel(label string) error {
+	if label == "" {
+		return errors.New("Invalid label: label is empty")
+	}
+	if !labelPattern.MatchString(strings.ToLower(label)) {
+		return fmt.Errorf("Invalid label %q: valid characters are [a-z0-9_-.]", label)
+	}
+	return nil
+}
+
+// labelNames returns the names of all labels
+func (c *Client) labelNames() ([]string, error) {
+	var res ArrayResponse
+	err := c.action("label.get_labels", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting torrent labels: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting torrent labels: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetLabels returns every label along with the number of torrents it is
+// applied to
+func (c *Client) GetLabels() ([]Label, error) {
+	err := c.requirePlugin("Label")
+	if err != nil {
+		return nil, err
+	}
+	names, err := c.labelNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var tree struct {
+		Result map[string][][]interface{} `json:"result"`
+		Error  RpcError                   `json:"error"`
+	}
+	err = c.action("core.get_filter_tree", "true, []", &tree)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting torrent labels: %w", err)
+	}
+	if tree.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting torrent labels: %s", tree.Error.Message)
+	}
+
+	counts := map[string]int{}
+	for _, entry := range tree.Result["label"] {
+		if len(entry) != 2 {
+			continue
+		}
+		name, _ := entry[0].(string)
+		count, _ := entry[1].(float64)
+		counts[name] = int(count)
+	}
+
+	labels := make([]Label, len(names))
+	for i, name := range names {
+		labels[i] = Label{Name: name, Count: counts[name]}
+	}
+
+	return labels, nil
+}
+
+// AddLabel creates a label
+func (c *Client) AddLabel(label string) error {
+	err := ValidateLabel(label)
+	if err != nil {
+		return err
+	}
+	err = c.requirePlugin("Label")
+	if err != nil {
+		return err
+	}
+
+	p, err := params(strings.ToLower(label))
+	if err != nil {
+		return fmt.Errorf("Error creating torrent label: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("label.add", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error creating torrent label: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error creating torrent label: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// RemoveLabel deletes a label, unlabelling its torrents
+func (c *Client) RemoveLabel(label string) error {
+	err := c.requirePlugin("Label")
+	if err != nil {
+		return err
+	}
+
+	p, err := params(strings.ToLower(label))
+	if err != nil {
+		return fmt.Errorf("Error removing torrent label: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("label.remove", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error removing torrent label: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error removing torrent label: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// GetLabelOptions returns the settings of a label
+func (c *Client) GetLabelOptions(label string) (LabelOptions, error) {
+	err := c.requirePlugin("Label")
+	if err != nil {
+		return LabelOptions{}, err
+	}
+
+	p, err := params(strings.ToLower(label))
+	if err != nil {
+		return LabelOptions{}, fmt.Errorf("Error getting torrent label options: %w", err)
+	}
+
+	var res LabelOptionsResponse
+	err = c.action("label.get_options", p, &res)
+	if err != nil {
+		return LabelOptions{}, fmt.Errorf("Error getting torrent label options: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return LabelOptions{}, fmt.Errorf("Error getting torrent label options: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// SetLabelOptions replaces the settings of a label
+func (c *Client) SetLabelOptions(label string, opts LabelOptions) error {
+	err := c.requirePlugin("Label")
+	if err != nil {
+		return err
+	}
+
+	if opts.AutoAddTrackers == nil {
+		opts.AutoAddTrackers = []string{}
+	}
+	p, err := params(strings.ToLower(label), opts)
+	if err != nil {
+		return fmt.Errorf("Error setting torrent label options: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("label.set_options", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error setting torrent label options: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error setting torrent label options: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// SetTorrentLabel sets the label for the given torrent, creating the label if
+// it does not exist yet
+func (c *Client) SetTorrentLabel(hash string, label string) error {
+	return c.SetTorrentsLabel([]string{hash}, label)
+}
+
+// SetTorrentsLabel sets the label for every given torrent, creating the label
+// if it does not exist yet
+func (c *Client) SetTorrentsLabel(hashes []string, label string) error {
+	err := ValidateLabel(label)
+	if err != nil {
+		return err
+	}
+	//Deluge only accepts lowercase labels
+	label = strings.ToLower(label)
+
+	err = c.requirePlugin("Label")
+	if err != nil {
+		return err
+	}
+
+	labels, err := c.labelNames()
+	if err != nil {
+		return err
+	}
+
+	if !contains(labels, label) {
+		err = c.AddLabel(label)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.setTorrentsLabel(hashes, label)
+}
+
+// ClearTorrentLabel removes the label from the given torrent
+func (c *Client) ClearTorrentLabel(hash string) error {
+	err := c.requirePlugin("Label")
+	if err != nil {
+		return err
+	}
+
+	return c.setTorrentsLabel([]string{hash}, "")
+}
+
+// setTorrentsLabel calls label.set_torrent for each torrent
+func (c *Client) setTorrentsLabel(hashes []string, label string) error {
+	for _, hash := range hashes {
+		p, err := params(hash, label)
+		if err != nil {
+			return fmt.Errorf("Error setting torrent label: %w", err)
+		}
+
+		var res BoolResponse
+		err = c.action("label.set_torrent", p, &res)
+		if err != nil {
+			return fmt.Errorf("Error setting torrent label for %s: %w", hash, err)
+		}
+		if res.Error.Code != 0 {
+			return fmt.Errorf("Error setting torrent label for %s: %s", hash, res.Error.Message)
+		}
+	}
+
+	return nil
+}
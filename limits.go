@@ -0,0 +1,215 @@
+package deluge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// GlobalLimits holds the daemon wide bandwidth and connection limits. Only
+// non-nil fields are sent by SetGlobalLimits.
+type GlobalLimits struct {
+	// Speeds are in KiB/s; -1 is unlimited
+	MaxDownloadSpeed *float64 `json:"max_download_speed,omitempty"`
+	MaxUploadSpeed   *float64 `json:"max_upload_speed,omitempty"`
+	// Connection limits; -1 is unlimited
+	MaxConnections         *int `json:"max_connections_global,omitempty"`
+	MaxUploadSlots         *int `json:"max_upload_slots_global,omitempty"`
+	MaxHalfOpenConnections *int `json:"max_half_open_connections,omitempty"`
+}
+
+// SetGlobalLimits applies the non-nil limits to the daemon
+func (c *Client) SetGlobalLimits(limits GlobalLimits) error {
+	var config map[string]interface{}
+	b, err := json.Marshal(limits)
+	if err == nil {
+		err = json.Unmarshal(b, &config)
+	}
+	if err != nil {
+		return fmt.Errorf("Error setting global limits: %w", err)
+	}
+
+	return c.SetRawConfig(config)
+}
+
+// GetGlobalLimits returns the daemon's current limits
+func (c *Client) GetGlobalLimits() (GlobalLimits, error) {
+	raw, err := c.GetRawConfig()
+	if err != nil {
+		return GlobalLimits{}, err
+	}
+
+	var limits GlobalLimits
+	b, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(b, &limits)
+	}
+	if err != nil {
+		return GlobalLimits{}, fmt.Errorf("Error getting global limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// throttleState is what a Throttle keeps in its state file
+type throttleState struct {
+	Previous GlobalLimits `json:"previous"`
+	Until    time.Time    `json:"until"`
+}
+
+// Throttle applies global limits for a while and then puts back the values
+// they replaced. The replaced values and the end time are kept in a JSON
+// state file, so a throttle started before a restart is still lifted as long
+// as Check or Run is called afterwards.
+type Throttle struct {
+	Path string
+
+	client *Client
+	mu     sync.Mutex
+	state  *throttleState
+}
+
+// NewThrottle loads the state file at path, if any
+func NewThrottle(c *Client, path string) (*Throttle, error) {
+	t := &Throttle{
+		Path:   path,
+		client: c,
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Error reading throttle state: %w", err)
+	}
+	if err == nil {
+		t.state = &throttleState{}
+		err = json.Unmarshal(b, t.state)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading throttle state: %w", err)
+		}
+	}
+
+	return t, nil
+}
+
+// Apply sets limits for duration d. Applying again while a throttle is
+// active extends it to the new end time and still restores the values from
+// before the first Apply.
+func (t *Throttle) Apply(limits GlobalLimits, d time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, err := t.client.GetGlobalLimits()
+	if err != nil {
+		return err
+	}
+
+	state := throttleState{}
+	if t.state != nil {
+		state.Previous = t.state.Previous
+	}
+	//Only remember the limits being replaced, and only the first time
+	if limits.MaxDownloadSpeed != nil && state.Previous.MaxDownloadSpeed == nil {
+		state.Previous.MaxDownloadSpeed = current.MaxDownloadSpeed
+	}
+	if limits.MaxUploadSpeed != nil && state.Previous.MaxUploadSpeed == nil {
+		state.Previous.MaxUploadSpeed = current.MaxUploadSpeed
+	}
+	if limits.MaxConnections != nil && state.Previous.MaxConnections == nil {
+		state.Previous.MaxConnections = current.MaxConnections
+	}
+	if limits.MaxUploadSlots != nil && state.Previous.MaxUploadSlots == nil {
+		state.Previous.MaxUploadSlots = current.MaxUploadSlots
+	}
+	if limits.MaxHalfOpenConnections != nil && state.Previous.MaxHalfOpenConnections == nil {
+		state.Previous.MaxHalfOpenConnections = current.MaxHalfOpenConnections
+	}
+	state.Until = time.Now().Add(d)
+
+	//Save before changing anything so the old values are never lost
+	err = writeJSON(t.Path, state)
+	if err != nil {
+		return fmt.Errorf("Error saving throttle state: %w", err)
+	}
+	t.state = &state
+
+	return t.client.SetGlobalLimits(limits)
+}
+
+// Until returns the time the active throttle ends, or false if none is active
+func (t *Throttle) Until() (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state == nil {
+		return time.Time{}, false
+	}
+	return t.state.Until, true
+}
+
+// Restore ends the active throttle now, putting back the replaced limits
+func (t *Throttle) Restore() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.restore()
+}
+
+// Check restores the replaced limits if the throttle has expired and reports
+// whether it did
+func (t *Throttle) Check() (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state == nil || time.Now().Before(t.state.Until) {
+		return false, nil
+	}
+	err := t.restore()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Run calls Check every interval until ctx is cancelled. Errors from Check
+// are passed to onError, if set, and do not stop the loop.
+func (t *Throttle) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := t.Check()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// restore puts back the replaced limits and removes the state file; the
+// caller holds t.mu
+func (t *Throttle) restore() error {
+	if t.state == nil {
+		return nil
+	}
+
+	err := t.client.SetGlobalLimits(t.state.Previous)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(t.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error removing throttle state: %w", err)
+	}
+	t.state = nil
+	return nil
+}
@@ -0,0 +1,143 @@
+package deluge
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConfig serves core.get_config and core.set_config from config
+func fakeConfig(f *fakeDeluge, config map[string]interface{}) func() map[string]interface{} {
+	var mu sync.Mutex
+	f.handle("core.get_config", func([]json.RawMessage) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return normalize(config)
+	})
+	f.handle("core.set_config", func(params []json.RawMessage) interface{} {
+		var changes map[string]interface{}
+		json.Unmarshal(params[0], &changes)
+		mu.Lock()
+		defer mu.Unlock()
+		for key, value := range changes {
+			config[key] = value
+		}
+		return nil
+	})
+	return func() map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return normalize(config).(map[string]interface{})
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	config := fakeConfig(f, map[string]interface{}{
+		"max_download_speed":     -1.0,
+		"max_upload_speed":       500.0,
+		"max_connections_global": 200,
+	})
+	c := newTestClient(t, f)
+	path := filepath.Join(t.TempDir(), "throttle.json")
+
+	th, err := NewThrottle(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := th.Until(); ok {
+		t.Errorf("new throttle is active")
+	}
+
+	err = th.Apply(GlobalLimits{MaxDownloadSpeed: Float(100), MaxUploadSpeed: Float(50)}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Applying again keeps the values from before the first Apply, and
+	//remembers limits it newly replaces
+	err = th.Apply(GlobalLimits{MaxUploadSpeed: Float(10), MaxConnections: Int(20)}, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg := config(); cfg["max_download_speed"] != 100.0 || cfg["max_upload_speed"] != 10.0 || cfg["max_connections_global"] != 20.0 {
+		t.Errorf("throttled config = %v", cfg)
+	}
+
+	//A throttle loaded from disk, e.g. after a restart, knows what to restore
+	th, err = NewThrottle(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	until, ok := th.Until()
+	if !ok || until.Before(time.Now().Add(time.Hour)) {
+		t.Errorf("reloaded Until = %v, %v", until, ok)
+	}
+	if restored, err := th.Check(); err != nil || restored {
+		t.Errorf("Check before expiry = %v, %v", restored, err)
+	}
+	if cfg := config(); cfg["max_upload_speed"] != 10.0 {
+		t.Errorf("restored before expiry: %v", cfg)
+	}
+
+	th.mu.Lock()
+	th.state.Until = time.Now().Add(-time.Second)
+	th.mu.Unlock()
+	restored, err := th.Check()
+	if err != nil || !restored {
+		t.Fatalf("Check after expiry = %v, %v", restored, err)
+	}
+	if cfg := config(); cfg["max_download_speed"] != -1.0 || cfg["max_upload_speed"] != 500.0 || cfg["max_connections_global"] != 200.0 {
+		t.Errorf("restored config = %v", cfg)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("state file left behind: %v", err)
+	}
+	if _, ok := th.Until(); ok {
+		t.Errorf("throttle still active after restoring")
+	}
+	if restored, err := th.Check(); err != nil || restored {
+		t.Errorf("second Check = %v, %v", restored, err)
+	}
+}
+
+func TestThrottleRestore(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	config := fakeConfig(f, map[string]interface{}{"max_download_speed": 300.0, "max_upload_speed": -1.0})
+	restores := 0
+	c := newTestClient(t, f)
+	path := filepath.Join(t.TempDir(), "throttle.json")
+
+	th, err := NewThrottle(c, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := th.Apply(GlobalLimits{MaxDownloadSpeed: Float(0)}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := config(); cfg["max_download_speed"] != 0.0 {
+		t.Errorf("throttled config = %v", cfg)
+	}
+
+	//Restore ends the throttle early and leaves untouched limits alone
+	f.handle("core.set_config", func(params []json.RawMessage) interface{} {
+		var changes map[string]interface{}
+		json.Unmarshal(params[0], &changes)
+		if _, ok := changes["max_upload_speed"]; ok || changes["max_download_speed"] != 300.0 {
+			t.Errorf("restore sent %v", changes)
+		}
+		restores++
+		return nil
+	})
+	if err := th.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if restores != 1 {
+		t.Errorf("core.set_config called %d times to restore, want 1", restores)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("state file left behind: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package metainfo
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// maxDepth bounds the nesting of lists and dictionaries accepted by Decode
+const maxDepth = 256
+
+// SyntaxError describes malformed bencoded data and where it was found
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("bencode: %s at offset %d", e.Msg, e.Offset)
+}
+
+// Decode strictly decodes a single bencoded value that must span all of b.
+// Integers decode to int64, strings to string, lists to []interface{} and
+// dictionaries to map[string]interface{}. Leading zeros, negative zero,
+// unsorted or duplicate dictionary keys and trailing data are rejected.
+func Decode(b []byte) (interface{}, error) {
+	d := decoder{data: b}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(b) {
+		return nil, d.error("trailing data")
+	}
+	return v, nil
+}
+
+// decoder walks bencoded data, remembering where the top level info
+// dictionary lies so it can be hashed byte for byte
+type decoder struct {
+	data  []byte
+	pos   int
+	depth int
+
+	infoStart int
+	infoEnd   int
+}
+
+func (d *decoder) error(msg string) error {
+	return &SyntaxError{Offset: d.pos, Msg: msg}
+}
+
+func (d *decoder) value() (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.error("unexpected end of data")
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		return d.integer()
+	case c == 'l':
+		return d.list()
+	case c == 'd':
+		return d.dict()
+	case c >= '0' && c <= '9':
+		return d.string()
+	}
+
+	return nil, d.error(fmt.Sprintf("unexpected byte %q", d.data[d.pos]))
+}
+
+// digits reads a decimal number up to the terminator, rejecting leading zeros
+func (d *decoder) digits(term byte, signed bool) (int64, error) {
+	start := d.pos
+	end := start
+	for end < len(d.data) && d.data[end] != term {
+		end++
+	}
+	if end >= len(d.data) {
+		return 0, d.error("unterminated number")
+	}
+
+	s := string(d.data[start:end])
+	digits := s
+	if signed && len(s) > 0 && s[0] == '-' {
+		digits = s[1:]
+		if digits == "0" {
+			return 0, d.error("negative zero")
+		}
+	}
+	if digits == "" {
+		return 0, d.error("empty number")
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, d.error("invalid number")
+		}
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, d.error("leading zero")
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, d.error("number out of range")
+	}
+	d.pos = end + 1
+	return n, nil
+}
+
+func (d *decoder) integer() (int64, error) {
+	d.pos++
+	return d.digits('e', true)
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.digits(':', false)
+	if err != nil {
+		return "", err
+	}
+	if n > int64(len(d.data)-d.pos) {
+		return "", d.error("string length exceeds data")
+	}
+	s := string(d.data[d.pos : d.pos+int(n)])
+	d.pos += int(n)
+	return s, nil
+}
+
+func (d *decoder) enter() error {
+	d.depth++
+	if d.depth > maxDepth {
+		return d.error("nesting too deep")
+	}
+	d.pos++
+	return nil
+}
+
+func (d *decoder) list() ([]interface{}, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	l := []interface{}{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, d.error("unterminated list")
+		}
+		if d.data[d.pos] == 'e' {
+			break
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+	}
+
+	d.pos++
+	d.depth--
+	return l, nil
+}
+
+func (d *decoder) dict() (map[string]interface{}, error) {
+	top := d.depth == 0
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	var last string
+	for i := 0; ; i++ {
+		if d.pos >= len(d.data) {
+			return nil, d.error("unterminated dictionary")
+		}
+		if d.data[d.pos] == 'e' {
+			break
+		}
+		if c := d.data[d.pos]; c < '0' || c > '9' {
+			return nil, d.error("dictionary key is not a string")
+		}
+		key, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && key <= last {
+			return nil, d.error(fmt.Sprintf("dictionary key %q out of order", key))
+		}
+		last = key
+
+		start := d.pos
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if top && key == "info" {
+			d.infoStart, d.infoEnd = start, d.pos
+		}
+		m[key] = v
+	}
+
+	d.pos++
+	d.depth--
+	return m, nil
+}
+
+// Encode bencodes v, which may be built from integers, strings, byte slices,
+// lists and string keyed maps. Dictionary keys are written in sorted order.
+func Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := encode(&buf, v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case int:
+		fmt.Fprintf(buf, "i%de", v)
+	case int64:
+		fmt.Fprintf(buf, "i%de", v)
+	case bool:
+		if v {
+			buf.WriteString("i1e")
+		} else {
+			buf.WriteString("i0e")
+		}
+	case string:
+		fmt.Fprintf(buf, "%d:%s", len(v), v)
+	case []byte:
+		fmt.Fprintf(buf, "%d:", len(v))
+		buf.Write(v)
+	case []string:
+		buf.WriteByte('l')
+		for _, s := range v {
+			fmt.Fprintf(buf, "%d:%s", len(s), s)
+		}
+		buf.WriteByte('e')
+	case []interface{}:
+		buf.WriteByte('l')
+		for _, item := range v {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, k := range keys {
+			fmt.Fprintf(buf, "%d:%s", len(k), k)
+			if err := encode(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("bencode: cannot encode %T", v)
+	}
+	return nil
+}
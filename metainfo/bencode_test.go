@@ -0,0 +1,181 @@
+package metainfo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testTorrents = []struct {
+	file       string
+	infoHash   string
+	infoHashV2 string
+	id         string
+	files      []File
+	private    bool
+}{
+	{
+		file:     "v1.torrent",
+		infoHash: "414e6d9bab12b00e182c996e601341300b9cdc72",
+		id:       "414e6d9bab12b00e182c996e601341300b9cdc72",
+		files: []File{
+			{Path: "sample/hello.txt", Length: 12},
+			{Path: "sample/sub/x.bin", Length: 32768},
+		},
+	},
+	{
+		file:       "v2.torrent",
+		infoHashV2: "f5c45fbdc7ab75176d3e2a6e9b00c3c6d1902ec8bff632ecf3a45a5a8145f379",
+		id:         "f5c45fbdc7ab75176d3e2a6e9b00c3c6d1902ec8",
+		files:      []File{{Path: "hello.txt", Length: 12}},
+	},
+	{
+		file:       "hybrid.torrent",
+		infoHash:   "a9dbd6865a5926d14e112d548f91e008b7105b5b",
+		infoHashV2: "c0aa1f8a69f6779ab589098c76462bded4cf15ad715c0197998fac57baacda0d",
+		id:         "a9dbd6865a5926d14e112d548f91e008b7105b5b",
+		files:      []File{{Path: "hello.txt", Length: 12}},
+		private:    true,
+	},
+}
+
+func TestParseHashes(t *testing.T) {
+	for _, tt := range testTorrents {
+		mi, err := Load(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if mi.InfoHash != tt.infoHash {
+			t.Errorf("%s: InfoHash = %q, want %q", tt.file, mi.InfoHash, tt.infoHash)
+		}
+		if mi.InfoHashV2 != tt.infoHashV2 {
+			t.Errorf("%s: InfoHashV2 = %q, want %q", tt.file, mi.InfoHashV2, tt.infoHashV2)
+		}
+		if mi.ID() != tt.id {
+			t.Errorf("%s: ID() = %q, want %q", tt.file, mi.ID(), tt.id)
+		}
+		if mi.Private != tt.private {
+			t.Errorf("%s: Private = %v, want %v", tt.file, mi.Private, tt.private)
+		}
+		if len(mi.Files) != len(tt.files) {
+			t.Fatalf("%s: Files = %v, want %v", tt.file, mi.Files, tt.files)
+		}
+		for i := range tt.files {
+			if mi.Files[i] != tt.files[i] {
+				t.Errorf("%s: Files[%d] = %v, want %v", tt.file, i, mi.Files[i], tt.files[i])
+			}
+		}
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"integer", "i42e", ""},
+		{"negative integer", "i-42e", ""},
+		{"zero", "i0e", ""},
+		{"sorted dictionary", "d1:ai1e1:bi2ee", ""},
+		{"leading zero", "i042e", "leading zero"},
+		{"leading zero in string length", "03:abc", "leading zero"},
+		{"negative zero", "i-0e", "negative zero"},
+		{"empty integer", "ie", "empty number"},
+		{"unsorted keys", "d1:bi1e1:ai2ee", "out of order"},
+		{"duplicate keys", "d1:ai1e1:ai2ee", "out of order"},
+		{"trailing data", "i1ei2e", "trailing data"},
+		{"string past end", "5:abc", "exceeds data"},
+		{"unterminated list", "li1e", "unterminated list"},
+		{"non-string key", "di1ei2ee", "not a string"},
+		{"depth limit", strings.Repeat("l", maxDepth+1) + strings.Repeat("e", maxDepth+1), "nesting too deep"},
+		{"at depth limit", strings.Repeat("l", maxDepth) + strings.Repeat("e", maxDepth), ""},
+	}
+
+	for _, tt := range tests {
+		_, err := Decode([]byte(tt.input))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("%s: expected error containing %q", tt.name, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: error %q does not contain %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, tt := range testTorrents {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := Decode(b)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		out, err := Encode(v)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if string(out) != string(b) {
+			t.Errorf("%s: Encode(Decode(b)) differs from b", tt.file)
+		}
+	}
+}
+
+func addSeeds(f *testing.F) {
+	for _, tt := range testTorrents {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Add([]byte("d4:infod4:name1:a12:piece lengthi16384e6:pieces0:6:lengthi0eee"))
+	f.Add([]byte("i-0e"))
+	f.Add([]byte("l" + strings.Repeat("l", maxDepth) + "e"))
+}
+
+func FuzzDecode(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, b []byte) {
+		v, err := Decode(b)
+		if err != nil {
+			return
+		}
+		//Strictly decoded data is canonical, so it must encode back unchanged
+		out, err := Encode(v)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		if string(out) != string(b) {
+			t.Fatalf("Encode(Decode(%q)) = %q", b, out)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, b []byte) {
+		mi, err := Parse(b)
+		if err != nil {
+			return
+		}
+		if len(mi.ID()) != 40 {
+			t.Fatalf("ID() = %q", mi.ID())
+		}
+		if mi.Length() < 0 {
+			t.Fatalf("Length() = %d", mi.Length())
+		}
+		m, err := ParseMagnet(mi.Magnet().String())
+		if err != nil {
+			t.Fatalf("ParseMagnet(Magnet()): %v", err)
+		}
+		if m.ID() != mi.ID() {
+			t.Fatalf("magnet ID() = %q, want %q", m.ID(), mi.ID())
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package metainfo
+
+import (
+	"crypto/sha1"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CreateOptions configures Create
+type CreateOptions struct {
+	// PieceLength is the piece size in bytes, a power of two of at least
+	// 16 KiB. Zero picks one based on the content size.
+	PieceLength int64
+	// Trackers are announce URLs, one tier per element
+	Trackers []string
+	// WebSeeds are HTTP URLs serving the content (url-list)
+	WebSeeds []string
+	Private  bool
+	Comment  string
+	// CreatedBy defaults to "go-deluge"
+	CreatedBy string
+	// Name overrides the torrent name, which defaults to the base name of
+	// the content
+	Name string
+}
+
+// source is a file to be hashed into the torrent
+type source struct {
+	path   string
+	elems  []string
+	length int64
+}
+
+// Create hashes the file or directory at root and returns the bencoded
+// .torrent describing it
+func Create(root string, opts CreateOptions) ([]byte, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []source
+	var total int64
+	if info.IsDir() {
+		err = filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || !fi.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, source{path: path,
+				elems: strings.Split(filepath.ToSlash(rel), "/"), length: fi.Size()})
+			total += fi.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(sources) == 0 {
+			return nil, errors.New("metainfo: no files to add")
+		}
+	} else {
+		sources = []source{{path: root, length: info.Size()}}
+		total = info.Size()
+	}
+
+	pieceLength := opts.PieceLength
+	if pieceLength == 0 {
+		pieceLength = choosePieceLength(total)
+	}
+	if pieceLength < 16<<10 || pieceLength&(pieceLength-1) != 0 {
+		return nil, errors.New("metainfo: piece length must be a power of two of at least 16 KiB")
+	}
+
+	pieces, err := hashPieces(sources, pieceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(root)
+	}
+	infoDict := map[string]interface{}{
+		"name":         name,
+		"piece length": pieceLength,
+		"pieces":       pieces,
+	}
+	if info.IsDir() {
+		files := make([]interface{}, len(sources))
+		for i, s := range sources {
+			files[i] = map[string]interface{}{"length": s.length, "path": s.elems}
+		}
+		infoDict["files"] = files
+	} else {
+		infoDict["length"] = total
+	}
+	if opts.Private {
+		infoDict["private"] = 1
+	}
+
+	createdBy := opts.CreatedBy
+	if createdBy == "" {
+		createdBy = "go-deluge"
+	}
+	torrent := map[string]interface{}{
+		"info":          infoDict,
+		"created by":    createdBy,
+		"creation date": time.Now().Unix(),
+	}
+	if len(opts.Trackers) > 0 {
+		torrent["announce"] = opts.Trackers[0]
+		tiers := make([]interface{}, len(opts.Trackers))
+		for i, tracker := range opts.Trackers {
+			tiers[i] = []string{tracker}
+		}
+		torrent["announce-list"] = tiers
+	}
+	if len(opts.WebSeeds) > 0 {
+		torrent["url-list"] = opts.WebSeeds
+	}
+	if opts.Comment != "" {
+		torrent["comment"] = opts.Comment
+	}
+
+	return Encode(torrent)
+}
+
+// CreateFile creates a torrent for root as Create does and writes it to target
+func CreateFile(root string, target string, opts CreateOptions) (*MetaInfo, error) {
+	b, err := Create(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(target, b, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// choosePieceLength aims for roughly 1500 pieces, between 16 KiB and 16 MiB
+func choosePieceLength(total int64) int64 {
+	length := int64(16 << 10)
+	for length < 16<<20 && total/length > 1500 {
+		length *= 2
+	}
+	return length
+}
+
+// hashPieces returns the concatenated SHA1 of every piece of the files read
+// back to back
+func hashPieces(sources []source, pieceLength int64) ([]byte, error) {
+	var pieces []byte
+	h := sha1.New()
+	var filled int64
+
+	for _, s := range sources {
+		f, err := os.Open(s.path)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			n, err := io.CopyN(h, f, pieceLength-filled)
+			filled += n
+			if filled == pieceLength {
+				pieces = h.Sum(pieces)
+				h.Reset()
+				filled = 0
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+		f.Close()
+	}
+	if filled > 0 {
+		pieces = h.Sum(pieces)
+	}
+
+	return pieces, nil
+}
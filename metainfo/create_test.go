@@ -0,0 +1,134 @@
+package metainfo
+
+import (
+	"crypto/sha1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testContent returns n deterministic bytes starting at offset, so the
+// expected pieces can be computed over the files read back to back
+func testContent(offset, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte((offset + i) % 251)
+	}
+	return b
+}
+
+func TestCreateRoundTrip(t *testing.T) {
+	const pieceLength = 16 << 10
+	tests := []struct {
+		name     string
+		files    []File
+		infoHash string
+	}{
+		{
+			//a.bin ends exactly on the first piece boundary and b.bin on the
+			//third, so c.txt starts a new piece
+			name: "dir",
+			files: []File{
+				{Path: "dir/a.bin", Length: pieceLength},
+				{Path: "dir/b.bin", Length: 2 * pieceLength},
+				{Path: "dir/sub/c.txt", Length: 5},
+			},
+			infoHash: "da2dada001af3acb58882379ccc2b008c82d112e",
+		},
+		{
+			name:     "single.bin",
+			files:    []File{{Path: "single.bin", Length: pieceLength}},
+			infoHash: "3facea51c570684c7caa46c28976c2904a164f54",
+		},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		var data []byte
+		for _, f := range tt.files {
+			path := filepath.Join(dir, filepath.FromSlash(f.Path))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			content := testContent(len(data), int(f.Length))
+			if err := ioutil.WriteFile(path, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, content...)
+		}
+		root := filepath.Join(dir, tt.name)
+
+		b, err := Create(root, CreateOptions{PieceLength: pieceLength, Trackers: []string{"http://tracker/announce"}})
+		if err != nil {
+			t.Fatalf("%s: Create: %v", tt.name, err)
+		}
+		mi, err := Parse(b)
+		if err != nil {
+			t.Fatalf("%s: Parse: %v", tt.name, err)
+		}
+
+		if mi.Name != tt.name {
+			t.Errorf("%s: Name = %q", tt.name, mi.Name)
+		}
+		if mi.PieceLength != pieceLength {
+			t.Errorf("%s: PieceLength = %d, want %d", tt.name, mi.PieceLength, pieceLength)
+		}
+		if len(mi.Files) != len(tt.files) {
+			t.Fatalf("%s: Files = %v, want %v", tt.name, mi.Files, tt.files)
+		}
+		for i := range tt.files {
+			if mi.Files[i] != tt.files[i] {
+				t.Errorf("%s: Files[%d] = %v, want %v", tt.name, i, mi.Files[i], tt.files[i])
+			}
+		}
+		if mi.Announce != "http://tracker/announce" {
+			t.Errorf("%s: Announce = %q", tt.name, mi.Announce)
+		}
+
+		var pieces []byte
+		for off := 0; off < len(data); off += pieceLength {
+			end := off + pieceLength
+			if end > len(data) {
+				end = len(data)
+			}
+			sum := sha1.Sum(data[off:end])
+			pieces = append(pieces, sum[:]...)
+		}
+		v, err := Decode(mi.Info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.(map[string]interface{})["pieces"]; got != string(pieces) {
+			t.Errorf("%s: pieces do not match the content", tt.name)
+		}
+
+		//The info hash must not depend on the creation date or the run
+		if mi.InfoHash != tt.infoHash {
+			t.Errorf("%s: InfoHash = %q, want %q", tt.name, mi.InfoHash, tt.infoHash)
+		}
+		again, err := Create(root, CreateOptions{PieceLength: pieceLength, Comment: "again"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mi2, err := Parse(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mi2.InfoHash != mi.InfoHash {
+			t.Errorf("%s: InfoHash changed between runs: %q, %q", tt.name, mi.InfoHash, mi2.InfoHash)
+		}
+	}
+}
+
+func TestCreateInvalidPieceLength(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "a"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int64{1 << 10, 20000} {
+		if _, err := Create(dir, CreateOptions{PieceLength: n}); err == nil {
+			t.Errorf("PieceLength %d: expected error", n)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package metainfo
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Magnet is a parsed magnet link
+type Magnet struct {
+	// InfoHash is the hex encoded v1 info hash (xt=urn:btih)
+	InfoHash string
+	// InfoHashV2 is the hex encoded v2 info hash (xt=urn:btmh)
+	InfoHashV2 string
+	// Name is the display name (dn)
+	Name string
+	// Length is the exact total size in bytes (xl)
+	Length int64
+	// Trackers are the tracker URLs (tr)
+	Trackers []string
+	// WebSeeds are the web seed URLs (ws)
+	WebSeeds []string
+	// SelectOnly lists the indices of the files to download (so)
+	SelectOnly []int
+}
+
+// ParseMagnet parses a magnet link. Both hex and base32 v1 hashes are
+// accepted; at least one v1 or v2 hash is required.
+func ParseMagnet(uri string) (*Magnet, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "magnet" {
+		return nil, fmt.Errorf("metainfo: not a magnet link: %q", uri)
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Magnet{
+		Name:     q.Get("dn"),
+		Trackers: q["tr"],
+		WebSeeds: q["ws"],
+	}
+
+	for _, xt := range q["xt"] {
+		switch {
+		case strings.HasPrefix(xt, "urn:btih:"):
+			m.InfoHash, err = btih(strings.TrimPrefix(xt, "urn:btih:"))
+		case strings.HasPrefix(xt, "urn:btmh:"):
+			m.InfoHashV2, err = btmh(strings.TrimPrefix(xt, "urn:btmh:"))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.InfoHash == "" && m.InfoHashV2 == "" {
+		return nil, errors.New("metainfo: magnet link has no info hash")
+	}
+
+	if xl := q.Get("xl"); xl != "" {
+		m.Length, err = strconv.ParseInt(xl, 10, 64)
+		if err != nil || m.Length < 0 {
+			return nil, fmt.Errorf("metainfo: invalid magnet length %q", xl)
+		}
+	}
+	if so := q.Get("so"); so != "" {
+		m.SelectOnly, err = selectOnly(so)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// ID returns the hash Deluge identifies the torrent by: the v1 info hash, or
+// the truncated v2 hash for v2 only magnets
+func (m *Magnet) ID() string {
+	if m.InfoHash != "" {
+		return m.InfoHash
+	}
+	return m.InfoHashV2[:40]
+}
+
+// String formats the magnet link
+func (m *Magnet) String() string {
+	var params []string
+	if m.InfoHash != "" {
+		params = append(params, "xt=urn:btih:"+m.InfoHash)
+	}
+	if m.InfoHashV2 != "" {
+		params = append(params, "xt=urn:btmh:1220"+m.InfoHashV2)
+	}
+	if m.Name != "" {
+		params = append(params, "dn="+url.QueryEscape(m.Name))
+	}
+	if m.Length > 0 {
+		params = append(params, "xl="+strconv.FormatInt(m.Length, 10))
+	}
+	for _, tr := range m.Trackers {
+		params = append(params, "tr="+url.QueryEscape(tr))
+	}
+	for _, ws := range m.WebSeeds {
+		params = append(params, "ws="+url.QueryEscape(ws))
+	}
+	if len(m.SelectOnly) > 0 {
+		so := make([]string, len(m.SelectOnly))
+		for i, n := range m.SelectOnly {
+			so[i] = strconv.Itoa(n)
+		}
+		params = append(params, "so="+strings.Join(so, ","))
+	}
+
+	return "magnet:?" + strings.Join(params, "&")
+}
+
+// Magnet returns a magnet link for the torrent
+func (mi *MetaInfo) Magnet() *Magnet {
+	return &Magnet{
+		InfoHash:   mi.InfoHash,
+		InfoHashV2: mi.InfoHashV2,
+		Name:       mi.Name,
+		Length:     mi.Length(),
+		Trackers:   mi.Trackers(),
+		WebSeeds:   mi.URLList,
+	}
+}
+
+// btih normalises a hex or base32 v1 info hash to lowercase hex
+func btih(hash string) (string, error) {
+	switch len(hash) {
+	case 40:
+		if _, err := hex.DecodeString(hash); err == nil {
+			return strings.ToLower(hash), nil
+		}
+	case 32:
+		b, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash))
+		if err == nil {
+			return hex.EncodeToString(b), nil
+		}
+	}
+	return "", fmt.Errorf("metainfo: invalid btih info hash %q", hash)
+}
+
+// btmh extracts the hex SHA256 from a v2 multihash
+func btmh(hash string) (string, error) {
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) != 34 || b[0] != 0x12 || b[1] != 0x20 {
+		return "", fmt.Errorf("metainfo: invalid btmh info hash %q", hash)
+	}
+	return hex.EncodeToString(b[2:]), nil
+}
+
+// MaxSelectOnly bounds the number of file indices a magnet's so parameter
+// may expand to, so a short link cannot demand a huge allocation
+const MaxSelectOnly = 4096
+
+// selectOnly expands a list of file indices and ranges such as "0,2,4-6"
+func selectOnly(so string) ([]int, error) {
+	var files []int
+	for _, part := range strings.Split(so, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("metainfo: invalid magnet file selection %q", so)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil, fmt.Errorf("metainfo: invalid magnet file selection %q", so)
+			}
+		}
+		if last-first >= MaxSelectOnly-len(files) {
+			return nil, fmt.Errorf("metainfo: magnet file selection %q selects more than %d files", so, MaxSelectOnly)
+		}
+		for n := first; n <= last; n++ {
+			files = append(files, n)
+		}
+	}
+	return files, nil
+}
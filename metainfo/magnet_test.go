@@ -0,0 +1,159 @@
+package metainfo
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	testHash   = "414e6d9bab12b00e182c996e601341300b9cdc72"
+	testHashV2 = "f5c45fbdc7ab75176d3e2a6e9b00c3c6d1902ec8bff632ecf3a45a5a8145f379"
+)
+
+func TestParseMagnet(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want Magnet
+		err  string
+	}{
+		{
+			name: "hex btih",
+			uri:  "magnet:?xt=urn:btih:" + strings.ToUpper(testHash) + "&dn=sample&xl=32780",
+			want: Magnet{InfoHash: testHash, Name: "sample", Length: 32780},
+		},
+		{
+			name: "base32 btih",
+			uri:  "magnet:?xt=urn:btih:IFHG3G5LCKYA4GBMTFXGAE2BGAFZZXDS",
+			want: Magnet{InfoHash: testHash},
+		},
+		{
+			name: "lowercase base32 btih",
+			uri:  "magnet:?xt=urn:btih:ifhg3g5lckya4gbmtfxgae2bgafzzxds",
+			want: Magnet{InfoHash: testHash},
+		},
+		{
+			name: "btmh",
+			uri:  "magnet:?xt=urn:btmh:1220" + testHashV2,
+			want: Magnet{InfoHashV2: testHashV2},
+		},
+		{
+			name: "hybrid",
+			uri:  "magnet:?xt=urn:btih:" + testHash + "&xt=urn:btmh:1220" + testHashV2,
+			want: Magnet{InfoHash: testHash, InfoHashV2: testHashV2},
+		},
+		{
+			name: "trackers and web seeds",
+			uri:  "magnet:?xt=urn:btih:" + testHash + "&tr=udp%3A%2F%2Fa%3A80&tr=http%3A%2F%2Fb%2Fannounce&ws=http%3A%2F%2Fc%2F",
+			want: Magnet{
+				InfoHash: testHash,
+				Trackers: []string{"udp://a:80", "http://b/announce"},
+				WebSeeds: []string{"http://c/"},
+			},
+		},
+		{
+			name: "so ranges",
+			uri:  "magnet:?xt=urn:btih:" + testHash + "&so=0,2,4-6,9-9",
+			want: Magnet{InfoHash: testHash, SelectOnly: []int{0, 2, 4, 5, 6, 9}},
+		},
+		{
+			name: "so at limit",
+			uri:  "magnet:?xt=urn:btih:" + testHash + "&so=0-" + strconv.Itoa(MaxSelectOnly-1),
+			want: Magnet{InfoHash: testHash, SelectOnly: rangeOf(0, MaxSelectOnly-1)},
+		},
+		{name: "so over limit", uri: "magnet:?xt=urn:btih:" + testHash + "&so=0-50000000", err: "more than"},
+		{name: "so over limit in total", uri: "magnet:?xt=urn:btih:" + testHash + "&so=0-4000,5000-6000", err: "more than"},
+		{name: "so reversed range", uri: "magnet:?xt=urn:btih:" + testHash + "&so=6-4", err: "invalid magnet file selection"},
+		{name: "so negative", uri: "magnet:?xt=urn:btih:" + testHash + "&so=-1", err: "invalid magnet file selection"},
+		{name: "not a magnet", uri: "http://example.com/?xt=urn:btih:" + testHash, err: "not a magnet link"},
+		{name: "no hash", uri: "magnet:?dn=sample", err: "no info hash"},
+		{name: "short btih", uri: "magnet:?xt=urn:btih:414e6d9b", err: "invalid btih"},
+		{name: "btmh not sha256", uri: "magnet:?xt=urn:btmh:1120" + testHashV2, err: "invalid btmh"},
+		{name: "negative length", uri: "magnet:?xt=urn:btih:" + testHash + "&xl=-1", err: "invalid magnet length"},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMagnet(tt.uri)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error %v, want error containing %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*m, tt.want) {
+			t.Errorf("%s: ParseMagnet = %+v, want %+v", tt.name, *m, tt.want)
+		}
+	}
+}
+
+func TestMagnetID(t *testing.T) {
+	m := Magnet{InfoHashV2: testHashV2}
+	if m.ID() != testHashV2[:40] {
+		t.Errorf("v2 ID() = %q, want %q", m.ID(), testHashV2[:40])
+	}
+	m.InfoHash = testHash
+	if m.ID() != testHash {
+		t.Errorf("hybrid ID() = %q, want %q", m.ID(), testHash)
+	}
+}
+
+func TestMagnetStringRoundTrip(t *testing.T) {
+	tests := []Magnet{
+		{InfoHash: testHash},
+		{InfoHashV2: testHashV2},
+		{
+			InfoHash:   testHash,
+			InfoHashV2: testHashV2,
+			Name:       "a name & more",
+			Length:     32780,
+			Trackers:   []string{"udp://a:80", "http://b/announce?k=v"},
+			WebSeeds:   []string{"http://c/"},
+			SelectOnly: []int{0, 2, 3},
+		},
+	}
+
+	for _, want := range tests {
+		s := want.String()
+		m, err := ParseMagnet(s)
+		if err != nil {
+			t.Errorf("ParseMagnet(%q): %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(*m, want) {
+			t.Errorf("ParseMagnet(%q) = %+v, want %+v", s, *m, want)
+		}
+	}
+}
+
+func TestMetaInfoMagnet(t *testing.T) {
+	for _, tt := range testTorrents {
+		mi, err := Load("testdata/" + tt.file)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		m, err := ParseMagnet(mi.Magnet().String())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if m.InfoHash != tt.infoHash || m.InfoHashV2 != tt.infoHashV2 || m.ID() != tt.id {
+			t.Errorf("%s: magnet hashes %q %q, want %q %q", tt.file, m.InfoHash, m.InfoHashV2, tt.infoHash, tt.infoHashV2)
+		}
+		if m.Length != mi.Length() {
+			t.Errorf("%s: magnet length %d, want %d", tt.file, m.Length, mi.Length())
+		}
+	}
+}
+
+func rangeOf(first, last int) []int {
+	var n []int
+	for i := first; i <= last; i++ {
+		n = append(n, i)
+	}
+	return n
+}
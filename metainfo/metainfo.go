@@ -0,0 +1,283 @@
+// Package metainfo decodes and creates .torrent files and magnet links and
+// computes their info hashes.
+package metainfo
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File is a single file described by a torrent
+type File struct {
+	// Path is the file's path relative to the torrent's root, using "/"
+	Path   string
+	Length int64
+}
+
+// MetaInfo is the decoded contents of a .torrent file
+type MetaInfo struct {
+	Name string
+	// InfoHash is the hex encoded SHA1 of the info dictionary; empty for
+	// v2 only torrents
+	InfoHash string
+	// InfoHashV2 is the hex encoded SHA256 of the info dictionary; empty for
+	// v1 only torrents
+	InfoHashV2   string
+	PieceLength  int64
+	Private      bool
+	Files        []File
+	Announce     string
+	AnnounceList [][]string
+	URLList      []string
+	CreationDate time.Time
+	Comment      string
+	CreatedBy    string
+
+	// Info holds the raw bencoded info dictionary
+	Info []byte
+}
+
+// Load reads and parses the .torrent at path
+func Load(path string) (*MetaInfo, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse decodes a .torrent
+func Parse(b []byte) (*MetaInfo, error) {
+	d := decoder{data: b}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(b) {
+		return nil, d.error("trailing data")
+	}
+
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("metainfo: torrent is not a dictionary")
+	}
+	info, ok := root["info"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("metainfo: missing info dictionary")
+	}
+
+	mi := &MetaInfo{
+		Info:         b[d.infoStart:d.infoEnd],
+		Announce:     str(root["announce"]),
+		Comment:      str(root["comment"]),
+		CreatedBy:    str(root["created by"]),
+		Name:         str(info["name"]),
+		PieceLength:  num(info["piece length"]),
+		Private:      num(info["private"]) == 1,
+		AnnounceList: tiers(root["announce-list"]),
+	}
+	if date := num(root["creation date"]); date > 0 {
+		mi.CreationDate = time.Unix(date, 0).UTC()
+	}
+	switch u := root["url-list"].(type) {
+	case string:
+		mi.URLList = []string{u}
+	case []interface{}:
+		for _, s := range u {
+			if s, ok := s.(string); ok {
+				mi.URLList = append(mi.URLList, s)
+			}
+		}
+	}
+
+	if mi.Name == "" {
+		return nil, errors.New("metainfo: missing name")
+	}
+	if mi.PieceLength <= 0 {
+		return nil, errors.New("metainfo: missing piece length")
+	}
+
+	_, v1 := info["pieces"].(string)
+	v2 := num(info["meta version"]) == 2
+	if !v1 && !v2 {
+		return nil, errors.New("metainfo: missing pieces")
+	}
+	if v1 {
+		sum := sha1.Sum(mi.Info)
+		mi.InfoHash = hex.EncodeToString(sum[:])
+		mi.Files, err = filesV1(mi.Name, info)
+	} else {
+		mi.Files, err = filesV2(mi.Name, info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v2 {
+		sum := sha256.Sum256(mi.Info)
+		mi.InfoHashV2 = hex.EncodeToString(sum[:])
+	}
+
+	return mi, nil
+}
+
+// ID returns the hash Deluge identifies the torrent by: the v1 info hash, or
+// the truncated v2 hash for v2 only torrents
+func (mi *MetaInfo) ID() string {
+	if mi.InfoHash != "" {
+		return mi.InfoHash
+	}
+	return mi.InfoHashV2[:40]
+}
+
+// Length returns the total size of all files in the torrent
+func (mi *MetaInfo) Length() int64 {
+	var n int64
+	for _, f := range mi.Files {
+		n += f.Length
+	}
+	return n
+}
+
+// Trackers returns every tracker URL in the torrent, announce-list tiers
+// first, without duplicates
+func (mi *MetaInfo) Trackers() []string {
+	var trackers []string
+	seen := map[string]bool{}
+	for _, tier := range mi.AnnounceList {
+		for _, tracker := range tier {
+			if !seen[tracker] {
+				seen[tracker] = true
+				trackers = append(trackers, tracker)
+			}
+		}
+	}
+	if mi.Announce != "" && !seen[mi.Announce] {
+		trackers = append(trackers, mi.Announce)
+	}
+	return trackers
+}
+
+// filesV1 lists the files of a v1 or hybrid info dictionary, skipping
+// padding files
+func filesV1(name string, info map[string]interface{}) ([]File, error) {
+	list, ok := info["files"].([]interface{})
+	if !ok {
+		length, ok := info["length"].(int64)
+		if !ok || length < 0 {
+			return nil, errors.New("metainfo: missing length")
+		}
+		return []File{{Path: name, Length: length}}, nil
+	}
+
+	var files []File
+	for _, f := range list {
+		f, ok := f.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("metainfo: malformed file entry")
+		}
+		length, ok := f["length"].(int64)
+		if !ok || length < 0 {
+			return nil, errors.New("metainfo: malformed file length")
+		}
+		if attr, _ := f["attr"].(string); strings.ContainsRune(attr, 'p') {
+			continue
+		}
+		parts, ok := f["path"].([]interface{})
+		if !ok || len(parts) == 0 {
+			return nil, errors.New("metainfo: malformed file path")
+		}
+		elems := []string{name}
+		for _, p := range parts {
+			p, ok := p.(string)
+			if !ok {
+				return nil, errors.New("metainfo: malformed file path")
+			}
+			elems = append(elems, p)
+		}
+		files = append(files, File{Path: path.Join(elems...), Length: length})
+	}
+	return files, nil
+}
+
+// filesV2 lists the files of a v2 file tree
+func filesV2(name string, info map[string]interface{}) ([]File, error) {
+	tree, ok := info["file tree"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("metainfo: missing file tree")
+	}
+
+	var files []File
+	var walk func(dir string, node map[string]interface{}) error
+	walk = func(dir string, node map[string]interface{}) error {
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			child, ok := node[k].(map[string]interface{})
+			if !ok {
+				return errors.New("metainfo: malformed file tree")
+			}
+			if leaf, ok := child[""].(map[string]interface{}); ok {
+				length, ok := leaf["length"].(int64)
+				if !ok || length < 0 {
+					return errors.New("metainfo: malformed file length")
+				}
+				files = append(files, File{Path: path.Join(dir, k), Length: length})
+				continue
+			}
+			if err := walk(path.Join(dir, k), child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk("", tree); err != nil {
+		return nil, err
+	}
+	//Single file v2 torrents name the file after the torrent
+	if !(len(files) == 1 && files[0].Path == name) {
+		for i := range files {
+			files[i].Path = path.Join(name, files[i].Path)
+		}
+	}
+	return files, nil
+}
+
+func tiers(v interface{}) [][]string {
+	list, _ := v.([]interface{})
+	var out [][]string
+	for _, tier := range list {
+		tier, _ := tier.([]interface{})
+		var urls []string
+		for _, u := range tier {
+			if u, ok := u.(string); ok {
+				urls = append(urls, u)
+			}
+		}
+		if len(urls) > 0 {
+			out = append(out, urls)
+		}
+	}
+	return out
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func num(v interface{}) int64 {
+	n, _ := v.(int64)
+	return n
+}
@@ -0,0 +1,152 @@
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrMethodUnavailable is returned, instead of waiting for deluge-web to time
+// out, when a method is not in the daemon's method list; usually the plugin
+// providing it is not enabled or the daemon's version does not have it. The
+// client's methods wrap it, use errors.As to find it.
+type ErrMethodUnavailable struct {
+	Method string
+}
+
+func (e ErrMethodUnavailable) Error() string {
+	return fmt.Sprintf("method %s is not available on the daemon", e.Method)
+}
+
+// MethodListRetry is how long calls go unchecked after fetching the method
+// list failed before it is fetched again
+var MethodListRetry = time.Minute
+
+// uncheckedNamespaces are served by deluge-web itself or always present, so
+// they are never looked up in the daemon's method list
+var uncheckedNamespaces = []string{"web.", "auth.", "daemon."}
+
+// Capabilities describes what the connected daemon supports
+type Capabilities struct {
+	// Methods lists every RPC method the daemon exposes, in order
+	Methods []string
+	// Plugins lists the enabled plugins
+	Plugins []string
+}
+
+// Has reports whether the daemon exposes method
+func (caps Capabilities) Has(method string) bool {
+	i := sort.SearchStrings(caps.Methods, method)
+	return i < len(caps.Methods) && caps.Methods[i] == method
+}
+
+// Namespaces returns the distinct method prefixes, e.g. "core" and "label"
+func (caps Capabilities) Namespaces() []string {
+	var namespaces []string
+	for _, method := range caps.Methods {
+		namespace := strings.SplitN(method, ".", 2)[0]
+		if len(namespaces) == 0 || namespaces[len(namespaces)-1] != namespace {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// RefreshMethods fetches daemon.get_method_list and replaces the client's
+// cached copy
+func (c *Client) RefreshMethods() ([]string, error) {
+	return c.refreshMethods(context.Background())
+}
+
+func (c *Client) refreshMethods(ctx context.Context) ([]string, error) {
+	var res ArrayResponse
+	err := c.actionContext(ctx, "daemon.get_method_list", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting method list: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting method list: %s", res.Error.Message)
+	}
+
+	methods := make(map[string]bool, len(res.Result))
+	for _, method := range res.Result {
+		methods[method] = true
+	}
+	c.methodMu.Lock()
+	c.methods = methods
+	c.methodsFailed = time.Time{}
+	c.methodMu.Unlock()
+
+	return res.Result, nil
+}
+
+// InvalidateMethods drops the cached method list, and any failure to fetch
+// it, so the next call fetches it again
+func (c *Client) InvalidateMethods() {
+	c.methodMu.Lock()
+	c.methods = nil
+	c.methodsFailed = time.Time{}
+	c.methodMu.Unlock()
+}
+
+// checkMethod returns ErrMethodUnavailable if the daemon does not expose
+// method. The method list is fetched on first use and fetched again once on a
+// miss, as a plugin may have been enabled since. If fetching fails calls are
+// let through rather than blocked, without trying again for MethodListRetry.
+func (c *Client) checkMethod(ctx context.Context, method string) error {
+	for _, namespace := range uncheckedNamespaces {
+		if strings.HasPrefix(method, namespace) {
+			return nil
+		}
+	}
+
+	c.methodMu.Lock()
+	methods := c.methods
+	failed := c.methodsFailed
+	c.methodMu.Unlock()
+
+	if methods[method] {
+		return nil
+	}
+	if methods == nil && !failed.IsZero() && time.Since(failed) < MethodListRetry {
+		return nil
+	}
+
+	_, err := c.refreshMethods(ctx)
+	if err != nil {
+		//A cancelled call says nothing about the daemon
+		if ctx.Err() == nil {
+			c.methodMu.Lock()
+			c.methodsFailed = time.Now()
+			c.methodMu.Unlock()
+		}
+		return nil
+	}
+	c.methodMu.Lock()
+	methods = c.methods
+	c.methodMu.Unlock()
+
+	if !methods[method] {
+		return ErrMethodUnavailable{Method: method}
+	}
+	return nil
+}
+
+// Capabilities returns the daemon's methods and enabled plugins, refreshing
+// both caches
+func (c *Client) Capabilities() (Capabilities, error) {
+	methods, err := c.RefreshMethods()
+	if err != nil {
+		return Capabilities{}, err
+	}
+	plugins, err := c.RefreshPlugins()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	sorted := append([]string(nil), methods...)
+	sort.Strings(sorted)
+	return Capabilities{Methods: sorted, Plugins: plugins}, nil
+}
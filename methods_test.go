@@ -0,0 +1,167 @@
+package deluge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckMethod(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_listen_port", func([]json.RawMessage) interface{} { return 6881 })
+	c := newTestClient(t, f)
+
+	if _, err := c.GetListenPort(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.CallRaw(context.Background(), "label.get_labels", nil)
+	if _, ok := err.(ErrMethodUnavailable); !ok {
+		t.Errorf("CallRaw(label.get_labels) error = %v, want ErrMethodUnavailable", err)
+	}
+	//A miss fetches the list again before giving up
+	if n := len(f.called("daemon.get_method_list")); n != 2 {
+		t.Errorf("daemon.get_method_list called %d times, want 2", n)
+	}
+	if n := len(f.called("label.get_labels")); n != 0 {
+		t.Errorf("unavailable method was sent")
+	}
+
+	if _, err := c.GetListenPort(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.called("daemon.get_method_list")); n != 2 {
+		t.Errorf("cached method fetched the list again")
+	}
+}
+
+func TestCheckMethodPluginEnabled(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{} })
+	c := newTestClient(t, f)
+	if _, err := c.RefreshPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetLabels(); err == nil {
+		t.Fatal("GetLabels succeeded without the Label plugin")
+	}
+
+	//Label is enabled after the method list was cached
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Label"} })
+	f.handle("label.get_labels", func([]json.RawMessage) interface{} { return []string{"movies"} })
+	f.handle("core.get_filter_tree", func([]json.RawMessage) interface{} {
+		return map[string][][]interface{}{"label": {{"movies", 2}}}
+	})
+	labels, err := c.GetLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 1 || labels[0] != (Label{Name: "movies", Count: 2}) {
+		t.Errorf("GetLabels = %v", labels)
+	}
+}
+
+func TestMethodUnavailableWrapped(t *testing.T) {
+	//The plugin is reported enabled but its methods are missing
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Label"} })
+	c := newTestClient(t, f)
+
+	_, err := c.GetLabels()
+	var unavailable ErrMethodUnavailable
+	if !errors.As(err, &unavailable) || unavailable.Method != "label.get_labels" {
+		t.Errorf("GetLabels error = %v, want ErrMethodUnavailable", err)
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "Error getting torrent labels: ") {
+		t.Errorf("GetLabels error = %v", err)
+	}
+}
+
+func TestRefreshPluginsInvalidatesMethods(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Label", "Scheduler"} })
+	f.handle("core.get_listen_port", func([]json.RawMessage) interface{} { return 6881 })
+	c := newTestClient(t, f)
+	if _, err := c.RefreshPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	c.RefreshMethods()
+	before := len(f.called("daemon.get_method_list"))
+
+	//The same plugins in another order keep the method list
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Scheduler", "Label"} })
+	c.RefreshPlugins()
+	c.GetListenPort()
+	if n := len(f.called("daemon.get_method_list")); n != before {
+		t.Errorf("method list fetched again for unchanged plugins")
+	}
+
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Label"} })
+	c.RefreshPlugins()
+	c.methodMu.Lock()
+	methods := c.methods
+	c.methodMu.Unlock()
+	if methods != nil {
+		t.Errorf("method list kept after the plugins changed")
+	}
+}
+
+func TestCheckMethodFailure(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_listen_port", func([]json.RawMessage) interface{} { return 6881 })
+	f.handle("daemon.get_method_list", func([]json.RawMessage) interface{} {
+		return RpcError{Message: "Not connected", Code: 1}
+	})
+	c := newTestClient(t, f)
+
+	//NewClient already tried once; the failure is remembered
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetListenPort(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(f.called("daemon.get_method_list")); n != 1 {
+		t.Errorf("daemon.get_method_list called %d times, want 1", n)
+	}
+
+	c.methodMu.Lock()
+	c.methodsFailed = time.Now().Add(-MethodListRetry)
+	c.methodMu.Unlock()
+	c.GetListenPort()
+	if n := len(f.called("daemon.get_method_list")); n != 2 {
+		t.Errorf("after MethodListRetry daemon.get_method_list called %d times, want 2", n)
+	}
+
+	c.InvalidateMethods()
+	c.GetListenPort()
+	if n := len(f.called("daemon.get_method_list")); n != 3 {
+		t.Errorf("after InvalidateMethods daemon.get_method_list called %d times, want 3", n)
+	}
+}
+
+func TestCheckMethodContext(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	c := newTestClient(t, f)
+	c.InvalidateMethods()
+	before := len(f.called("daemon.get_method_list"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.CallRaw(ctx, "core.get_listen_port", nil)
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("CallRaw with a cancelled context: error %v", err)
+	}
+	if n := len(f.called("daemon.get_method_list")); n != before {
+		t.Errorf("method list fetched with a cancelled context")
+	}
+
+	//The cancelled fetch is not remembered as a failure
+	c.methodMu.Lock()
+	failed := c.methodsFailed
+	c.methodMu.Unlock()
+	if !failed.IsZero() {
+		t.Errorf("cancelled fetch recorded as a failure")
+	}
+}
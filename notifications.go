@@ -0,0 +1,72 @@
+package deluge
+
+import (
+	"fmt"
+)
+
+// NotificationsConfig holds the Notifications plugin's email settings
+type NotificationsConfig struct {
+	SMTPEnabled    bool     `json:"smtp_enabled"`
+	SMTPHost       string   `json:"smtp_host"`
+	SMTPPort       int      `json:"smtp_port"`
+	SMTPUser       string   `json:"smtp_user"`
+	SMTPPassword   string   `json:"smtp_pass"`
+	SMTPFrom       string   `json:"smtp_from"`
+	SMTPTLS        bool     `json:"smtp_tls"`
+	SMTPRecipients []string `json:"smtp_recipients"`
+	// Subscriptions maps a notification kind ("email") to the event names
+	// it is sent for, e.g. "TorrentFinishedEvent"
+	Subscriptions map[string][]string `json:"subscriptions"`
+}
+
+// GetNotificationsConfig returns the Notifications plugin settings
+func (c *Client) GetNotificationsConfig() (NotificationsConfig, error) {
+	err := c.requirePlugin("Notifications")
+	if err != nil {
+		return NotificationsConfig{}, err
+	}
+
+	var res struct {
+		Result NotificationsConfig `json:"result"`
+		Error  RpcError            `json:"error"`
+	}
+	err = c.action("notifications.get_config", "", &res)
+	if err != nil {
+		return NotificationsConfig{}, fmt.Errorf("Error getting notifications config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return NotificationsConfig{}, fmt.Errorf("Error getting notifications config: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// SetNotificationsConfig replaces the Notifications plugin settings
+func (c *Client) SetNotificationsConfig(cfg NotificationsConfig) error {
+	err := c.requirePlugin("Notifications")
+	if err != nil {
+		return err
+	}
+
+	if cfg.SMTPRecipients == nil {
+		cfg.SMTPRecipients = []string{}
+	}
+	if cfg.Subscriptions == nil {
+		cfg.Subscriptions = map[string][]string{"email": {}}
+	}
+	p, err := params(cfg)
+	if err != nil {
+		return fmt.Errorf("Error setting notifications config: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("notifications.set_config", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error setting notifications config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error setting notifications config: %s", res.Error.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TorrentOptions holds the per torrent settings changed by
+// SetTorrentOptions. Only non-nil fields are sent to the daemon.
+type TorrentOptions struct {
+	// Speeds are in KiB/s; -1 is unlimited
+	MaxDownloadSpeed *float64 `json:"max_download_speed,omitempty"`
+	MaxUploadSpeed   *float64 `json:"max_upload_speed,omitempty"`
+	// Connection limits; -1 is unlimited
+	MaxConnections      *int     `json:"max_connections,omitempty"`
+	MaxUploadSlots      *int     `json:"max_upload_slots,omitempty"`
+	AutoManaged         *bool    `json:"auto_managed,omitempty"`
+	StopAtRatio         *bool    `json:"stop_at_ratio,omitempty"`
+	StopRatio           *float64 `json:"stop_ratio,omitempty"`
+	RemoveAtRatio       *bool    `json:"remove_at_ratio,omitempty"`
+	MoveCompleted       *bool    `json:"move_completed,omitempty"`
+	MoveCompletedPath   *string  `json:"move_completed_path,omitempty"`
+	PrioritizeFirstLast *bool    `json:"prioritize_first_last_pieces,omitempty"`
+	SequentialDownload  *bool    `json:"sequential_download,omitempty"`
+	SuperSeeding        *bool    `json:"super_seeding,omitempty"`
+	Owner               *string  `json:"owner,omitempty"`
+}
+
+// torrentOptionsStatus are the status keys TorrentOptions are read back from.
+// Deluge 1.3 and 2.x name some of them differently.
+type torrentOptionsStatus struct {
+	MaxDownloadSpeed    *float64 `json:"max_download_speed"`
+	MaxUploadSpeed      *float64 `json:"max_upload_speed"`
+	MaxConnections      *int     `json:"max_connections"`
+	MaxUploadSlots      *int     `json:"max_upload_slots"`
+	AutoManaged         *bool    `json:"is_auto_managed"`
+	StopAtRatio         *bool    `json:"stop_at_ratio"`
+	StopRatio           *float64 `json:"stop_ratio"`
+	RemoveAtRatio       *bool    `json:"remove_at_ratio"`
+	MoveCompleted       *bool    `json:"move_completed"`
+	MoveOnCompleted     *bool    `json:"move_on_completed"`
+	MoveCompletedPath   *string  `json:"move_completed_path"`
+	MoveOnCompletedPath *string  `json:"move_on_completed_path"`
+	PrioritizeFirstLast *bool    `json:"prioritize_first_last"`
+	SequentialDownload  *bool    `json:"sequential_download"`
+	SuperSeeding        *bool    `json:"super_seeding"`
+	Owner               *string  `json:"owner"`
+}
+
+// torrentOptionsKeys lists the status keys read by GetTorrentOptions
+var torrentOptionsKeys = []string{"max_download_speed", "max_upload_speed",
+	"max_connections", "max_upload_slots", "is_auto_managed", "stop_at_ratio",
+	"stop_ratio", "remove_at_ratio", "move_completed", "move_on_completed",
+	"move_completed_path", "move_on_completed_path", "prioritize_first_last",
+	"sequential_download", "super_seeding", "owner"}
+
+// Bool returns a pointer to v, for use in option structs
+func Bool(v bool) *bool { return &v }
+
+// Int returns a pointer to v, for use in option structs
+func Int(v int) *int { return &v }
+
+// Float returns a pointer to v, for use in option structs
+func Float(v float64) *float64 { return &v }
+
+// String returns a pointer to v, for use in option structs
+func String(v string) *string { return &v }
+
+// SetTorrentOptions applies opts to the torrents specified by info hash
+func (c *Client) SetTorrentOptions(hashes []string, opts TorrentOptions) error {
+	p, err := params(hashes, opts)
+	if err != nil {
+		return fmt.Errorf("Error setting torrent options: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("core.set_torrent_options", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error setting torrent options: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error setting torrent options: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// GetTorrentOptions returns the current options of the torrent specified by
+// info hash
+func (c *Client) GetTorrentOptions(hash string) (TorrentOptions, error) {
+	p, err := params(hash, torrentOptionsKeys)
+	if err != nil {
+		return TorrentOptions{}, fmt.Errorf("Error getting torrent options: %w", err)
+	}
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  RpcError        `json:"error"`
+	}
+	err = c.action("core.get_torrent_status", p, &res)
+	if err != nil {
+		return TorrentOptions{}, fmt.Errorf("Error getting torrent options: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return TorrentOptions{}, fmt.Errorf("Error getting torrent options: %s", res.Error.Message)
+	}
+
+	var status torrentOptionsStatus
+	if len(res.Result) == 0 || strings.TrimSpace(string(res.Result)) == "{}" {
+		return TorrentOptions{}, fmt.Errorf("Error getting torrent options: torrent %s not found", hash)
+	}
+	err = json.Unmarshal(res.Result, &status)
+	if err != nil {
+		return TorrentOptions{}, fmt.Errorf("Error getting torrent options: %w", err)
+	}
+
+	opts := TorrentOptions{
+		MaxDownloadSpeed:    status.MaxDownloadSpeed,
+		MaxUploadSpeed:      status.MaxUploadSpeed,
+		MaxConnections:      status.MaxConnections,
+		MaxUploadSlots:      status.MaxUploadSlots,
+		AutoManaged:         status.AutoManaged,
+		StopAtRatio:         status.StopAtRatio,
+		StopRatio:           status.StopRatio,
+		RemoveAtRatio:       status.RemoveAtRatio,
+		MoveCompleted:       status.MoveCompleted,
+		MoveCompletedPath:   status.MoveCompletedPath,
+		PrioritizeFirstLast: status.PrioritizeFirstLast,
+		SequentialDownload:  status.SequentialDownload,
+		SuperSeeding:        status.SuperSeeding,
+		Owner:               status.Owner,
+	}
+	if opts.MoveCompleted == nil {
+		opts.MoveCompleted = status.MoveOnCompleted
+	}
+	if opts.MoveCompletedPath == nil {
+		opts.MoveCompletedPath = status.MoveOnCompletedPath
+	}
+
+	return opts, nil
+}
+
+// RatioPolicy describes what happens to a torrent once it reaches a share
+// ratio
+type RatioPolicy struct {
+	// Enabled turns the limit on; when false the torrent seeds indefinitely
+	Enabled bool
+	Ratio   float64
+	// Remove removes the torrent at the ratio instead of pausing it
+	Remove bool
+}
+
+// SetTorrentRatioPolicy applies the ratio policy to the torrents specified by
+// info hash
+func (c *Client) SetTorrentRatioPolicy(hashes []string, policy RatioPolicy) error {
+	opts := TorrentOptions{
+		StopAtRatio:   Bool(policy.Enabled),
+		RemoveAtRatio: Bool(policy.Enabled && policy.Remove),
+	}
+	if policy.Enabled {
+		opts.StopRatio = Float(policy.Ratio)
+	}
+
+	return c.SetTorrentOptions(hashes, opts)
+}
+
+// GetTorrentRatioPolicy returns the ratio policy of the torrent specified by
+// info hash
+func (c *Client) GetTorrentRatioPolicy(hash string) (RatioPolicy, error) {
+	opts, err := c.GetTorrentOptions(hash)
+	if err != nil {
+		return RatioPolicy{}, err
+	}
+
+	var policy RatioPolicy
+	if opts.StopAtRatio != nil {
+		policy.Enabled = *opts.StopAtRatio
+	}
+	if opts.StopRatio != nil {
+		policy.Ratio = *opts.StopRatio
+	}
+	if opts.RemoveAtRatio != nil {
+		policy.Remove = *opts.RemoveAtRatio
+	}
+
+	return policy, nil
+}
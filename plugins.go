@@ -0,0 +1,294 @@
+package deluge
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// pluginEvents are the daemon events that change the set of enabled plugins
+var pluginEvents = []string{"PluginEnabledEvent", "PluginDisabledEvent"}
+
+// RefreshPlugins fetches the enabled plugins from the daemon and replaces the
+// client's cached copy. The cached method list is dropped if the plugins
+// changed, as they provide methods.
+func (c *Client) RefreshPlugins() ([]string, error) {
+	var plugins ArrayResponse
+	err := c.action("core.get_enabled_plugins", "", &plugins)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting enabled plugins: %w", err)
+	}
+	if plugins.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting enabled plugins: %s", plugins.Error.Message)
+	}
+
+	c.pluginMu.Lock()
+	changed := c.plugins != nil && !samePlugins(c.plugins, plugins.Result)
+	c.plugins = plugins.Result
+	c.pluginMu.Unlock()
+	if changed {
+		c.InvalidateMethods()
+	}
+
+	return plugins.Result, nil
+}
+
+// samePlugins reports whether a and b hold the same plugins in any order
+func samePlugins(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, name := range a {
+		if !contains(b, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// InvalidatePlugins drops the cached plugin list so the next plugin check
+// fetches it again
+func (c *Client) InvalidatePlugins() {
+	c.pluginMu.Lock()
+	c.plugins = nil
+	c.pluginMu.Unlock()
+}
+
+// requirePlugin returns an error unless the named plugin is enabled. The
+// cached plugin list is used when available and refreshed once on a miss.
+func (c *Client) requirePlugin(name string) error {
+	c.pluginMu.Lock()
+	cached := c.plugins
+	c.pluginMu.Unlock()
+
+	if cached != nil && contains(cached, name) {
+		return nil
+	}
+
+	plugins, err := c.RefreshPlugins()
+	if err != nil {
+		return err
+	}
+	if !contains(plugins, name) {
+		return fmt.Errorf("%s Plugin not detected - are you sure it is enabled?", name)
+	}
+
+	return nil
+}
+
+// WatchPluginEvents subscribes to the daemon's plugin events and invalidates
+// the cached plugin and method lists whenever a plugin is enabled or
+// disabled. It polls every interval until ctx is cancelled.
+func (c *Client) WatchPluginEvents(ctx context.Context, interval time.Duration) error {
+	for _, event := range pluginEvents {
+		p, err := params(event)
+		if err != nil {
+			return err
+		}
+		var res BoolResponse
+		err = c.action("web.register_event_listener", p, &res)
+		if err != nil {
+			return fmt.Errorf("Error registering event listener: %w", err)
+		}
+		if res.Error.Code != 0 {
+			return fmt.Errorf("Error registering event listener: %s", res.Error.Message)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		var res struct {
+			Result []json.RawMessage `json:"result"`
+			Error  RpcError          `json:"error"`
+		}
+		err := c.action("web.get_events", "", &res)
+		if err != nil {
+			return fmt.Errorf("Error getting events: %w", err)
+		}
+		if res.Error.Code != 0 {
+			return fmt.Errorf("Error getting events: %s", res.Error.Message)
+		}
+		if len(res.Result) > 0 {
+			c.InvalidatePlugins()
+			c.InvalidateMethods()
+		}
+	}
+}
+
+// PluginInfo describes an installed plugin
+type PluginInfo struct {
+	Name        string `json:"Name"`
+	Version     string `json:"Version"`
+	Author      string `json:"Author"`
+	AuthorEmail string `json:"Author-email"`
+	HomePage    string `json:"Home-page"`
+	License     string `json:"License"`
+	Summary     string `json:"Summary"`
+	Description string `json:"Description"`
+}
+
+// PluginInfoResponse is the result of web.get_plugin_info
+type PluginInfoResponse struct {
+	Id     int        `json:"id"`
+	Result PluginInfo `json:"result"`
+	Error  RpcError   `json:"error"`
+}
+
+// GetAvailablePlugins returns the names of all installed plugins, enabled or
+// not
+func (c *Client) GetAvailablePlugins() ([]string, error) {
+	var res ArrayResponse
+	err := c.action("core.get_available_plugins", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting available plugins: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting available plugins: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetEnabledPlugins returns the names of the enabled plugins
+func (c *Client) GetEnabledPlugins() ([]string, error) {
+	return c.RefreshPlugins()
+}
+
+// EnablePlugin enables an installed plugin
+func (c *Client) EnablePlugin(name string) error {
+	return c.setPluginEnabled("core.enable_plugin", name, "enabling")
+}
+
+// DisablePlugin disables a plugin
+func (c *Client) DisablePlugin(name string) error {
+	return c.setPluginEnabled("core.disable_plugin", name, "disabling")
+}
+
+func (c *Client) setPluginEnabled(method string, name string, verb string) error {
+	defer c.InvalidatePlugins()
+	defer c.InvalidateMethods()
+
+	p, err := params(name)
+	if err != nil {
+		return fmt.Errorf("Error %s plugin: %w", verb, err)
+	}
+
+	var res BoolResponse
+	err = c.action(method, p, &res)
+	if err != nil {
+		return fmt.Errorf("Error %s plugin: %w", verb, err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error %s plugin: %s", verb, res.Error.Message)
+	}
+
+	return nil
+}
+
+// GetPluginInfo returns the metadata of an installed plugin
+func (c *Client) GetPluginInfo(name string) (PluginInfo, error) {
+	p, err := params(name)
+	if err != nil {
+		return PluginInfo{}, fmt.Errorf("Error getting plugin info: %w", err)
+	}
+
+	var res PluginInfoResponse
+	err = c.action("web.get_plugin_info", p, &res)
+	if err != nil {
+		return PluginInfo{}, fmt.Errorf("Error getting plugin info: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return PluginInfo{}, fmt.Errorf("Error getting plugin info: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// UploadPlugin installs a plugin .egg on the daemon. The egg is uploaded to
+// deluge-web and installed with web.upload_plugin, falling back to sending it
+// straight to core.upload_plugin if the web upload is unavailable.
+func (c *Client) UploadPlugin(filename string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("Error reading plugin: %w", err)
+	}
+
+	var res BoolResponse
+	path, err := c.upload(filename, data)
+	if err == nil {
+		p, perr := params(filename, path)
+		if perr != nil {
+			return fmt.Errorf("Error uploading plugin: %w", perr)
+		}
+		err = c.action("web.upload_plugin", p, &res)
+	} else {
+		p, perr := params(filename, base64.StdEncoding.EncodeToString(data))
+		if perr != nil {
+			return fmt.Errorf("Error uploading plugin: %w", perr)
+		}
+		err = c.action("core.upload_plugin", p, &res)
+	}
+	if err != nil {
+		return fmt.Errorf("Error uploading plugin: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error uploading plugin: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// EnsurePlugin enables the named plugin if needed and waits, up to timeout,
+// until its RPC namespace responds
+func (c *Client) EnsurePlugin(name string, timeout time.Duration) error {
+	enabled, err := c.RefreshPlugins()
+	if err != nil {
+		return err
+	}
+
+	if !contains(enabled, name) {
+		available, err := c.GetAvailablePlugins()
+		if err != nil {
+			return err
+		}
+		if !contains(available, name) {
+			return fmt.Errorf("Error enabling plugin: %s is not installed", name)
+		}
+		err = c.EnablePlugin(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	namespace := strings.ToLower(name) + "."
+	deadline := time.Now().Add(timeout)
+	for {
+		methods, err := c.RefreshMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			if strings.HasPrefix(method, namespace) {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Error enabling plugin: %s did not respond after %s", name, timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
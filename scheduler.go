@@ -0,0 +1,262 @@
+package deluge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScheduleState is what the Scheduler plugin does during an hour of the week
+type ScheduleState int
+
+const (
+	// ScheduleNormal applies the daemon's usual limits
+	ScheduleNormal ScheduleState = iota
+	// ScheduleLimited applies the Scheduler's low limits
+	ScheduleLimited
+	// SchedulePaused pauses all torrents
+	SchedulePaused
+)
+
+var scheduleStates = map[string]ScheduleState{
+	"normal":  ScheduleNormal,
+	"limited": ScheduleLimited,
+	"paused":  SchedulePaused,
+}
+
+// Schedule is the Scheduler plugin's week, indexed by day (Monday is 0) and
+// hour
+type Schedule [7][24]ScheduleState
+
+var scheduleDays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// SchedulerConfig holds the Scheduler plugin settings
+type SchedulerConfig struct {
+	// Speeds used while limited, in KiB/s; -1 is unlimited
+	LowDown float64 `json:"low_down"`
+	LowUp   float64 `json:"low_up"`
+	// Queue limits used while limited; -1 is unlimited
+	LowActive     int `json:"low_active"`
+	LowActiveDown int `json:"low_active_down"`
+	LowActiveUp   int `json:"low_active_up"`
+
+	Schedule Schedule `json:"-"`
+}
+
+// schedulerConfig is SchedulerConfig as sent by the plugin, whose
+// button_state is indexed by hour then day
+type schedulerConfig struct {
+	SchedulerConfig
+	ButtonState [][]ScheduleState `json:"button_state"`
+}
+
+// GetSchedulerConfig returns the Scheduler plugin settings
+func (c *Client) GetSchedulerConfig() (SchedulerConfig, error) {
+	err := c.requirePlugin("Scheduler")
+	if err != nil {
+		return SchedulerConfig{}, err
+	}
+
+	var res struct {
+		Result schedulerConfig `json:"result"`
+		Error  RpcError        `json:"error"`
+	}
+	err = c.action("scheduler.get_config", "", &res)
+	if err != nil {
+		return SchedulerConfig{}, fmt.Errorf("Error getting scheduler config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return SchedulerConfig{}, fmt.Errorf("Error getting scheduler config: %s", res.Error.Message)
+	}
+
+	cfg := res.Result.SchedulerConfig
+	for hour, days := range res.Result.ButtonState {
+		for day, state := range days {
+			if hour < 24 && day < 7 {
+				cfg.Schedule[day][hour] = state
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// SetSchedulerConfig replaces the Scheduler plugin settings
+func (c *Client) SetSchedulerConfig(cfg SchedulerConfig) error {
+	err := c.requirePlugin("Scheduler")
+	if err != nil {
+		return err
+	}
+
+	raw := schedulerConfig{SchedulerConfig: cfg, ButtonState: make([][]ScheduleState, 24)}
+	for hour := range raw.ButtonState {
+		raw.ButtonState[hour] = make([]ScheduleState, 7)
+		for day := range raw.ButtonState[hour] {
+			raw.ButtonState[hour][day] = cfg.Schedule[day][hour]
+		}
+	}
+
+	p, err := params(raw)
+	if err != nil {
+		return fmt.Errorf("Error setting scheduler config: %w", err)
+	}
+
+	var res BoolResponse
+	err = c.action("scheduler.set_config", p, &res)
+	if err != nil {
+		return fmt.Errorf("Error setting scheduler config: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return fmt.Errorf("Error setting scheduler config: %s", res.Error.Message)
+	}
+
+	return nil
+}
+
+// ParseSchedule builds a Schedule from rules applied in order over an all
+// normal week. See Schedule.Apply for the rule format.
+func ParseSchedule(rules ...string) (Schedule, error) {
+	var s Schedule
+	for _, rule := range rules {
+		err := s.Apply(rule)
+		if err != nil {
+			return Schedule{}, err
+		}
+	}
+	return s, nil
+}
+
+// Apply sets the hours matched by a rule of the form "<days> [<from>-<to>]
+// <state>", e.g. "weekdays 09:00-18:00 limited". Days are "daily",
+// "weekdays", "weekends", a day name or a range or comma separated list of
+// them ("mon-fri", "sat,sun"). Times are whole hours; a range ending at or
+// before its start runs past midnight into the next day, and a missing range
+// covers the whole day. The state is "normal", "limited" or "paused".
+func (s *Schedule) Apply(rule string) error {
+	fields := strings.Fields(strings.ToLower(rule))
+	if len(fields) != 2 && len(fields) != 3 {
+		return fmt.Errorf("Invalid schedule rule %q: expected \"<days> [<from>-<to>] <state>\"", rule)
+	}
+
+	days, err := parseScheduleDays(fields[0])
+	if err != nil {
+		return fmt.Errorf("Invalid schedule rule %q: %w", rule, err)
+	}
+	state, ok := scheduleStates[fields[len(fields)-1]]
+	if !ok {
+		return fmt.Errorf("Invalid schedule rule %q: unknown state %q", rule, fields[len(fields)-1])
+	}
+
+	from, to := 0, 24
+	if len(fields) == 3 {
+		bounds := strings.SplitN(fields[1], "-", 2)
+		if len(bounds) != 2 {
+			return fmt.Errorf("Invalid schedule rule %q: expected a time range like 09:00-18:00", rule)
+		}
+		from, err = parseScheduleHour(bounds[0])
+		if err == nil {
+			to, err = parseScheduleHour(bounds[1])
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid schedule rule %q: %w", rule, err)
+		}
+	}
+
+	hours := to - from
+	if hours <= 0 {
+		hours += 24
+	}
+	for _, day := range days {
+		for i := 0; i < hours; i++ {
+			hour := from + i
+			s[(day+hour/24)%7][hour%24] = state
+		}
+	}
+
+	return nil
+}
+
+// parseScheduleDays returns the day indexes named by a rule
+func parseScheduleDays(spec string) ([]int, error) {
+	switch spec {
+	case "daily", "everyday", "all":
+		return []int{0, 1, 2, 3, 4, 5, 6}, nil
+	case "weekdays":
+		return []int{0, 1, 2, 3, 4}, nil
+	case "weekends":
+		return []int{5, 6}, nil
+	}
+
+	var days []int
+	for _, part := range strings.Split(spec, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := parseScheduleDay(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = parseScheduleDay(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseScheduleDay accepts a day's name abbreviated to at least three letters
+func parseScheduleDay(name string) (int, error) {
+	if len(name) >= 3 {
+		for i, day := range scheduleDays {
+			if strings.HasPrefix(day, name) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", name)
+}
+
+// parseScheduleHour parses "HH:MM" or "HH" on a whole hour, allowing "24:00"
+func parseScheduleHour(s string) (int, error) {
+	parts := strings.SplitN(s, ":", 2)
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if len(parts) == 2 && parts[1] != "00" {
+		return 0, fmt.Errorf("invalid time %q: the Scheduler only works in whole hours", s)
+	}
+	return hour, nil
+}
+
+// String renders the schedule as a grid, one row per day and one column per
+// hour: "." is normal, "L" limited and "P" paused
+func (s Schedule) String() string {
+	var b strings.Builder
+	b.WriteString("    000000000011111111112222\n")
+	b.WriteString("    012345678901234567890123\n")
+	for day, hours := range s {
+		b.WriteString(strings.ToUpper(scheduleDays[day][:1]) + scheduleDays[day][1:3] + " ")
+		for _, state := range hours {
+			switch state {
+			case ScheduleLimited:
+				b.WriteByte('L')
+			case SchedulePaused:
+				b.WriteByte('P')
+			default:
+				b.WriteByte('.')
+			}
+		}
+		if day < 6 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package deluge
+
+import (
+	"strings"
+	"testing"
+)
+
+// cells lists the hours of a schedule set to a state, as [day, hour] pairs
+type cells [][2]int
+
+// hoursOf returns the cells of days from hour first to last inclusive
+func hoursOf(days []int, first, last int) cells {
+	var c cells
+	for _, day := range days {
+		for hour := first; hour <= last; hour++ {
+			c = append(c, [2]int{day, hour})
+		}
+	}
+	return c
+}
+
+func TestScheduleApply(t *testing.T) {
+	tests := []struct {
+		rule string
+		want cells
+	}{
+		{"mon 09:00-18:00 limited", hoursOf([]int{0}, 9, 17)},
+		{"Mon 9-18 LIMITED", hoursOf([]int{0}, 9, 17)},
+		{"weekdays 09:00-10:00 limited", hoursOf([]int{0, 1, 2, 3, 4}, 9, 9)},
+		{"weekends limited", hoursOf([]int{5, 6}, 0, 23)},
+		{"daily 00:00-01:00 limited", hoursOf([]int{0, 1, 2, 3, 4, 5, 6}, 0, 0)},
+		{"sat,tue limited", hoursOf([]int{1, 5}, 0, 23)},
+		{"wednesday limited", hoursOf([]int{2}, 0, 23)},
+		//Past midnight into the next day
+		{"fri 22:00-02:00 limited", append(hoursOf([]int{4}, 22, 23), hoursOf([]int{5}, 0, 1)...)},
+		//Sunday wraps to Monday
+		{"sun 23:00-01:00 limited", append(hoursOf([]int{6}, 23, 23), hoursOf([]int{0}, 0, 0)...)},
+		{"mon 20:00-24:00 limited", hoursOf([]int{0}, 20, 23)},
+		{"mon 00:00-24:00 limited", hoursOf([]int{0}, 0, 23)},
+		//Equal bounds cover 24 hours
+		{"tue 12:00-12:00 limited", append(hoursOf([]int{1}, 12, 23), hoursOf([]int{2}, 0, 11)...)},
+		{"fri-mon limited", hoursOf([]int{4, 5, 6, 0}, 0, 23)},
+		{"sat-sun 01:00-02:00 limited", hoursOf([]int{5, 6}, 1, 1)},
+	}
+
+	for _, tt := range tests {
+		var s Schedule
+		if err := s.Apply(tt.rule); err != nil {
+			t.Errorf("%q: %v", tt.rule, err)
+			continue
+		}
+		var want Schedule
+		for _, c := range tt.want {
+			want[c[0]][c[1]] = ScheduleLimited
+		}
+		if s != want {
+			t.Errorf("%q:\n%s\nwant:\n%s", tt.rule, s, want)
+		}
+	}
+}
+
+func TestScheduleApplyInvalid(t *testing.T) {
+	tests := []struct {
+		rule string
+		err  string
+	}{
+		{"mon 09:30-18:00 limited", "whole hours"},
+		{"mon 09:00-18:15 limited", "whole hours"},
+		{"mon 25:00-02:00 limited", "invalid time"},
+		{"mon -1-02:00 limited", "invalid time"},
+		{"mon 09:00 limited", "time range"},
+		{"mo limited", "unknown day"},
+		{"monkey limited", "unknown day"},
+		{"mon-funday limited", "unknown day"},
+		{"mon slow", "unknown state"},
+		{"limited", "expected"},
+		{"mon 09:00-18:00 limited now", "expected"},
+	}
+
+	for _, tt := range tests {
+		var s Schedule
+		err := s.Apply(tt.rule)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: error %v, want error containing %q", tt.rule, err, tt.err)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	s, err := ParseSchedule("daily limited", "weekends normal", "sun 02:00-04:00 paused")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"    000000000011111111112222",
+		"    012345678901234567890123",
+		"Mon LLLLLLLLLLLLLLLLLLLLLLLL",
+		"Tue LLLLLLLLLLLLLLLLLLLLLLLL",
+		"Wed LLLLLLLLLLLLLLLLLLLLLLLL",
+		"Thu LLLLLLLLLLLLLLLLLLLLLLLL",
+		"Fri LLLLLLLLLLLLLLLLLLLLLLLL",
+		"Sat ........................",
+		"Sun ..PP....................",
+	}, "\n")
+	if s.String() != want {
+		t.Errorf("String() =\n%s\nwant:\n%s", s, want)
+	}
+
+	if _, err := ParseSchedule("daily limited", "mon 09:30-10:00 paused"); err == nil {
+		t.Errorf("expected an error for an invalid rule")
+	}
+}
@@ -0,0 +1,206 @@
+package deluge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrNoSeedTimeLimiter is returned by SetTorrentSeedTime when no
+// SeedTimeLimiter has been attached to the client
+var ErrNoSeedTimeLimiter = errors.New("no SeedTimeLimiter attached; see NewSeedTimeLimiter")
+
+// SeedTimeAction is what a SeedTimeLimiter does with a torrent that has
+// seeded for its target time
+type SeedTimeAction int
+
+const (
+	// SeedTimePause pauses the torrent
+	SeedTimePause SeedTimeAction = iota
+	// SeedTimeRemove removes the torrent, keeping its data
+	SeedTimeRemove
+)
+
+// SeedTimeLimiter enforces seed time targets, which Deluge has no native
+// support for. Targets are kept only in a JSON state file on the machine
+// running the limiter, mapping info hash to seconds of seeding; the daemon
+// knows nothing about them. Nothing is enforced unless Check or Run is
+// called, and a target is dropped once it has been acted on or its torrent
+// has left the session.
+type SeedTimeLimiter struct {
+	Path   string
+	Action SeedTimeAction
+
+	client  *Client
+	mu      sync.Mutex
+	targets map[string]int
+}
+
+// NewSeedTimeLimiter loads the state file at path, if any, and attaches the
+// limiter to c so SetTorrentSeedTime records targets in it
+func NewSeedTimeLimiter(c *Client, path string, action SeedTimeAction) (*SeedTimeLimiter, error) {
+	l := &SeedTimeLimiter{
+		Path:    path,
+		Action:  action,
+		client:  c,
+		targets: map[string]int{},
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Error reading seed time state: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(b, &l.targets)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading seed time state: %w", err)
+		}
+	}
+
+	c.seedTime = l
+	return l, nil
+}
+
+// Set records the seed time target, in seconds, for the torrent specified by
+// info hash. Zero or less clears the target.
+func (l *SeedTimeLimiter) Set(hash string, seconds int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if seconds <= 0 {
+		delete(l.targets, hash)
+	} else {
+		l.targets[hash] = seconds
+	}
+	return l.save()
+}
+
+// Targets returns a copy of the recorded targets
+func (l *SeedTimeLimiter) Targets() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	targets := make(map[string]int, len(l.targets))
+	for hash, seconds := range l.targets {
+		targets[hash] = seconds
+	}
+	return targets
+}
+
+// Check reads the seeding time of every torrent with a target and pauses or
+// removes those that have reached it, returning their info hashes
+func (l *SeedTimeLimiter) Check() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.targets) == 0 {
+		return nil, nil
+	}
+	hashes := make([]string, 0, len(l.targets))
+	for hash := range l.targets {
+		hashes = append(hashes, hash)
+	}
+
+	p, err := params(map[string][]string{"id": hashes}, []string{"seeding_time"})
+	if err != nil {
+		return nil, fmt.Errorf("Error checking seed times: %w", err)
+	}
+	var res struct {
+		Result map[string]struct {
+			SeedingTime int `json:"seeding_time"`
+		} `json:"result"`
+		Error RpcError `json:"error"`
+	}
+	err = l.client.action("core.get_torrents_status", p, &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error checking seed times: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error checking seed times: %s", res.Error.Message)
+	}
+
+	var done []string
+	for _, hash := range hashes {
+		status, ok := res.Result[hash]
+		if !ok {
+			delete(l.targets, hash)
+			continue
+		}
+		if status.SeedingTime < l.targets[hash] {
+			continue
+		}
+
+		if l.Action == SeedTimeRemove {
+			err = l.client.RemoveTorrent(hash)
+		} else {
+			err = l.client.PauseTorrent(hash)
+		}
+		if err != nil {
+			l.save()
+			return done, err
+		}
+		delete(l.targets, hash)
+		done = append(done, hash)
+	}
+
+	return done, l.save()
+}
+
+// Run calls Check every interval until ctx is cancelled. Errors from Check
+// are passed to onError, if set, and do not stop the loop.
+func (l *SeedTimeLimiter) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := l.Check()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// save writes the targets to the state file; the caller holds l.mu
+func (l *SeedTimeLimiter) save() error {
+	err := writeJSON(l.Path, l.targets)
+	if err != nil {
+		return fmt.Errorf("Error saving seed time state: %w", err)
+	}
+	return nil
+}
+
+// writeJSON atomically replaces the file at path with v encoded as JSON
+func writeJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package deluge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// fakeSeedingTimes serves core.get_torrents_status with the seeding time of
+// the requested torrents that are in the session
+func fakeSeedingTimes(f *fakeDeluge, seeding map[string]int) {
+	f.handle("core.get_torrents_status", func(params []json.RawMessage) interface{} {
+		var filter struct {
+			ID []string `json:"id"`
+		}
+		json.Unmarshal(params[0], &filter)
+		status := map[string]interface{}{}
+		for _, hash := range filter.ID {
+			if seconds, ok := seeding[hash]; ok {
+				status[hash] = map[string]int{"seeding_time": seconds}
+			}
+		}
+		return status
+	})
+	f.handle("core.pause_torrent", func([]json.RawMessage) interface{} { return nil })
+	f.handle("core.remove_torrent", func([]json.RawMessage) interface{} { return true })
+}
+
+// calledWith returns the first parameter of every call to method
+func calledWith(f *fakeDeluge, method string) []string {
+	var args []string
+	for _, call := range f.called(method) {
+		var arg interface{}
+		json.Unmarshal(call.Params[0], &arg)
+		switch v := arg.(type) {
+		case string:
+			args = append(args, v)
+		case []interface{}:
+			for _, s := range v {
+				args = append(args, s.(string))
+			}
+		}
+	}
+	sort.Strings(args)
+	return args
+}
+
+func TestSeedTimeLimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		action SeedTimeAction
+		method string
+	}{
+		{"pause", SeedTimePause, "core.pause_torrent"},
+		{"remove", SeedTimeRemove, "core.remove_torrent"},
+	}
+
+	for _, tt := range tests {
+		f := newFakeDeluge("2.1.1")
+		fakeSeedingTimes(f, map[string]int{"done": 3600, "seeding": 60})
+		c := newTestClient(t, f)
+		path := filepath.Join(t.TempDir(), "seedtime.json")
+
+		l, err := NewSeedTimeLimiter(c, path, tt.action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for hash, seconds := range map[string]int{"done": 3600, "seeding": 600, "gone": 60} {
+			if err := c.SetTorrentSeedTime(hash, seconds); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		//A limiter started later picks up the recorded targets
+		l, err = NewSeedTimeLimiter(c, path, tt.action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"done": 3600, "seeding": 600, "gone": 60}; !reflect.DeepEqual(l.Targets(), want) {
+			t.Errorf("%s: reloaded targets = %v, want %v", tt.name, l.Targets(), want)
+		}
+
+		done, err := l.Check()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(done, []string{"done"}) {
+			t.Errorf("%s: Check = %v, want [done]", tt.name, done)
+		}
+		if got := calledWith(f, tt.method); !reflect.DeepEqual(got, []string{"done"}) {
+			t.Errorf("%s: %s called for %v", tt.name, tt.method, got)
+		}
+		if tt.action == SeedTimeRemove {
+			var removeData bool
+			json.Unmarshal(f.called("core.remove_torrent")[0].Params[1], &removeData)
+			if removeData {
+				t.Errorf("%s: data removed", tt.name)
+			}
+			if n := len(f.called("core.pause_torrent")); n != 0 {
+				t.Errorf("%s: torrent paused", tt.name)
+			}
+		}
+
+		//Reached and gone torrents are dropped, on disk as well
+		want := map[string]int{"seeding": 600}
+		if !reflect.DeepEqual(l.Targets(), want) {
+			t.Errorf("%s: targets = %v, want %v", tt.name, l.Targets(), want)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var saved map[string]int
+		json.Unmarshal(b, &saved)
+		if !reflect.DeepEqual(saved, want) {
+			t.Errorf("%s: state file = %s, want %v", tt.name, b, want)
+		}
+
+		if done, err := l.Check(); err != nil || len(done) != 0 {
+			t.Errorf("%s: second Check = %v, %v", tt.name, done, err)
+		}
+	}
+}
+
+func TestSeedTimeLimiterActionFails(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	fakeSeedingTimes(f, map[string]int{"done": 3600})
+	f.handle("core.pause_torrent", func([]json.RawMessage) interface{} {
+		return RpcError{Message: "Torrent busy", Code: 1}
+	})
+	c := newTestClient(t, f)
+
+	l, err := NewSeedTimeLimiter(c, filepath.Join(t.TempDir(), "seedtime.json"), SeedTimePause)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Set("done", 60)
+
+	if _, err := l.Check(); err == nil {
+		t.Errorf("expected the pause error")
+	}
+	if _, ok := l.Targets()["done"]; !ok {
+		t.Errorf("target dropped although the torrent was not paused")
+	}
+
+	l.Set("done", 0)
+	if len(l.Targets()) != 0 {
+		t.Errorf("Set(0) did not clear the target")
+	}
+}
+
+func TestSetTorrentSeedTimeWithoutLimiter(t *testing.T) {
+	c := newTestClient(t, newFakeDeluge("2.1.1"))
+	if err := c.SetTorrentSeedTime("a", 60); err != ErrNoSeedTimeLimiter {
+		t.Errorf("SetTorrentSeedTime error = %v, want ErrNoSeedTimeLimiter", err)
+	}
+}
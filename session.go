@@ -0,0 +1,182 @@
+package deluge
+
+import (
+	"fmt"
+)
+
+// SessionStatus holds the daemon wide transfer statistics. Rates are in
+// bytes per second and totals in bytes.
+type SessionStatus struct {
+	UploadRate          float64
+	DownloadRate        float64
+	PayloadUploadRate   float64
+	PayloadDownloadRate float64
+
+	TotalUpload          int64
+	TotalDownload        int64
+	TotalPayloadUpload   int64
+	TotalPayloadDownload int64
+
+	NumPeers               int
+	DHTNodes               int
+	HasIncomingConnections bool
+
+	DiskBlocksRead    int64
+	DiskBlocksWritten int64
+	DiskReadOps       int64
+	DiskWriteOps      int64
+
+	// Raw holds every value returned by the daemon, keyed as requested
+	Raw map[string]interface{}
+}
+
+// sessionKeys maps each Deluge 2.x session status key to its 1.3 name.
+// Deluge 1.3 reports the disk statistics through core.get_cache_status.
+var sessionKeys = [][2]string{
+	{"upload_rate", "upload_rate"},
+	{"download_rate", "download_rate"},
+	{"payload_upload_rate", "payload_upload_rate"},
+	{"payload_download_rate", "payload_download_rate"},
+	{"net.sent_bytes", "total_upload"},
+	{"net.recv_bytes", "total_download"},
+	{"net.sent_payload_bytes", "total_payload_upload"},
+	{"net.recv_payload_bytes", "total_payload_download"},
+	{"peer.num_peers_connected", "num_peers"},
+	{"dht.dht_nodes", "dht_nodes"},
+	{"net.has_incoming_connections", "has_incoming_connections"},
+	{"disk.num_blocks_read", "blocks_read"},
+	{"disk.num_blocks_written", "blocks_written"},
+	{"disk.num_read_ops", "reads"},
+	{"disk.num_write_ops", "writes"},
+}
+
+// cacheStatusKeys are the 1.3 names only available from core.get_cache_status
+var cacheStatusKeys = []string{"blocks_read", "blocks_written", "reads", "writes"}
+
+// GetSessionStatus returns the daemon's session statistics. With no keys
+// every SessionStatus field is fetched using the key names of the detected
+// version, or trying the Deluge 2.x names first and falling back to the 1.3
+// names when the version is unknown; otherwise only the given keys are
+// requested and the fields they correspond to are filled in.
+func (c *Client) GetSessionStatus(keys ...string) (SessionStatus, error) {
+	if len(keys) > 0 {
+		raw, err := c.sessionStatus(keys)
+		if err != nil {
+			return SessionStatus{}, err
+		}
+		return newSessionStatus(raw), nil
+	}
+
+	version := c.Version()
+	if !version.IsV1() {
+		v2 := make([]string, len(sessionKeys))
+		for i, k := range sessionKeys {
+			v2[i] = k[0]
+		}
+		raw, err := c.sessionStatus(v2)
+		if err == nil {
+			return newSessionStatus(raw), nil
+		}
+		if version.IsV2() {
+			return SessionStatus{}, err
+		}
+	}
+
+	//Deluge 1.3 rejects the 2.x key names
+	var v1 []string
+	for _, k := range sessionKeys {
+		if !contains(cacheStatusKeys, k[1]) {
+			v1 = append(v1, k[1])
+		}
+	}
+	raw, err := c.sessionStatus(v1)
+	if err != nil {
+		return SessionStatus{}, err
+	}
+
+	var cache struct {
+		Result map[string]interface{} `json:"result"`
+		Error  RpcError               `json:"error"`
+	}
+	err = c.action("core.get_cache_status", "", &cache)
+	if err != nil {
+		return SessionStatus{}, fmt.Errorf("Error getting cache status: %w", err)
+	}
+	if cache.Error.Code != 0 {
+		return SessionStatus{}, fmt.Errorf("Error getting cache status: %s", cache.Error.Message)
+	}
+	for key, value := range cache.Result {
+		if _, ok := raw[key]; !ok {
+			raw[key] = value
+		}
+	}
+
+	return newSessionStatus(raw), nil
+}
+
+// sessionStatus calls core.get_session_status
+func (c *Client) sessionStatus(keys []string) (map[string]interface{}, error) {
+	p, err := params(keys)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting session status: %w", err)
+	}
+
+	var res struct {
+		Result map[string]interface{} `json:"result"`
+		Error  RpcError               `json:"error"`
+	}
+	err = c.action("core.get_session_status", p, &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting session status: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting session status: %s", res.Error.Message)
+	}
+	if res.Result == nil {
+		res.Result = map[string]interface{}{}
+	}
+
+	return res.Result, nil
+}
+
+// newSessionStatus fills a SessionStatus from values keyed by either
+// version's names
+func newSessionStatus(raw map[string]interface{}) SessionStatus {
+	value := func(key string) float64 {
+		for _, k := range sessionKeys {
+			if k[0] != key {
+				continue
+			}
+			v, ok := raw[k[0]]
+			if !ok {
+				v = raw[k[1]]
+			}
+			//Deluge 1.3 reports has_incoming_connections as a bool
+			if b, ok := v.(bool); ok && b {
+				return 1
+			}
+			n, _ := v.(float64)
+			return n
+		}
+		return 0
+	}
+
+	return SessionStatus{
+		UploadRate:             value("upload_rate"),
+		DownloadRate:           value("download_rate"),
+		PayloadUploadRate:      value("payload_upload_rate"),
+		PayloadDownloadRate:    value("payload_download_rate"),
+		TotalUpload:            int64(value("net.sent_bytes")),
+		TotalDownload:          int64(value("net.recv_bytes")),
+		TotalPayloadUpload:     int64(value("net.sent_payload_bytes")),
+		TotalPayloadDownload:   int64(value("net.recv_payload_bytes")),
+		NumPeers:               int(value("peer.num_peers_connected")),
+		DHTNodes:               int(value("dht.dht_nodes")),
+		HasIncomingConnections: value("net.has_incoming_connections") > 0,
+		DiskBlocksRead:         int64(value("disk.num_blocks_read")),
+		DiskBlocksWritten:      int64(value("disk.num_blocks_written")),
+		DiskReadOps:            int64(value("disk.num_read_ops")),
+		DiskWriteOps:           int64(value("disk.num_write_ops")),
+		Raw:                    raw,
+	}
+}
@@ -0,0 +1,100 @@
+package deluge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatsSeries is a Stats plugin time series. Each value slice starts with the
+// most recent sample and steps back by Interval.
+type StatsSeries struct {
+	Interval   time.Duration
+	LastUpdate time.Time
+	// Length is the number of samples the plugin keeps per key
+	Length int
+	Values map[string][]float64
+}
+
+// GetStatsIntervals returns the sampling intervals, in seconds, the Stats
+// plugin keeps history for
+func (c *Client) GetStatsIntervals() ([]int, error) {
+	err := c.requirePlugin("Stats")
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Result []int    `json:"result"`
+		Error  RpcError `json:"error"`
+	}
+	err = c.action("stats.get_intervals", "", &res)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting stats intervals: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return nil, fmt.Errorf("Error getting stats intervals: %s", res.Error.Message)
+	}
+
+	return res.Result, nil
+}
+
+// GetStats returns the history of the given keys (e.g. "upload_rate",
+// "download_rate", "num_connections") sampled every interval seconds, which
+// must be one of GetStatsIntervals
+func (c *Client) GetStats(keys []string, interval int) (StatsSeries, error) {
+	err := c.requirePlugin("Stats")
+	if err != nil {
+		return StatsSeries{}, err
+	}
+
+	p, err := params(keys, interval)
+	if err != nil {
+		return StatsSeries{}, fmt.Errorf("Error getting stats: %w", err)
+	}
+
+	var res struct {
+		Result map[string]json.RawMessage `json:"result"`
+		Error  RpcError                   `json:"error"`
+	}
+	err = c.action("stats.get_stats", p, &res)
+	if err != nil {
+		return StatsSeries{}, fmt.Errorf("Error getting stats: %w", err)
+	}
+	if res.Error.Code != 0 {
+		return StatsSeries{}, fmt.Errorf("Error getting stats: %s", res.Error.Message)
+	}
+	//The plugin returns None for an interval it does not keep
+	if res.Result == nil {
+		return StatsSeries{}, fmt.Errorf("Error getting stats: unsupported interval %d", interval)
+	}
+
+	series := StatsSeries{
+		Interval: time.Duration(interval) * time.Second,
+		Values:   map[string][]float64{},
+	}
+	for key, raw := range res.Result {
+		var err error
+		switch key {
+		case "_last_update":
+			var ts float64
+			err = json.Unmarshal(raw, &ts)
+			series.LastUpdate = time.Unix(0, int64(ts*float64(time.Second)))
+		case "_length":
+			err = json.Unmarshal(raw, &series.Length)
+		default:
+			if strings.HasPrefix(key, "_") {
+				continue
+			}
+			var values []float64
+			err = json.Unmarshal(raw, &values)
+			series.Values[key] = values
+		}
+		if err != nil {
+			return StatsSeries{}, fmt.Errorf("Error getting stats: %s: %w", key, err)
+		}
+	}
+
+	return series, nil
+}
@@ -1,15 +1,12 @@
 package deluge
 
 import (
-	"bufio"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/naposproject/go-deluge/metainfo"
 )
 
 type RpcError struct {
@@ -17,6 +14,10 @@ type RpcError struct {
 	Code    int    `json:"code"`
 }
 
+func (e RpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
 type BoolResponse struct {
 	Id     int      `json:"id"`
 	Result bool     `json:"result"`
@@ -54,33 +55,56 @@ type TorrentResponse struct {
 
 // TorrentProperties is a string containing the json keys to grab via JSONRPC
 // it vastly speeds up the call due to only grabbing the required values
-var TorrentProperties string = "\"hash\", \"name\", \"total_size\", \"progress\", \"all_time_download\", \"total_uploaded\", \"ratio\", \"upload_payload_rate\", \"download_payload_rate\", \"eta\", \"label\", \"num_peers\", \"total_peers\", \"num_seeds\", \"total_seeds\", \"seeds_peers_ratio\", \"queue\", \"state\", \"time_added\", \"move_on_completed_path\""
+var TorrentProperties string = "\"hash\", \"name\", \"total_size\", \"progress\", \"all_time_download\", \"total_uploaded\", \"ratio\", \"upload_payload_rate\", \"download_payload_rate\", \"eta\", \"label\", \"num_peers\", \"total_peers\", \"num_seeds\", \"total_seeds\", \"seeds_peers_ratio\", \"queue\", \"state\", \"time_added\", \"move_on_completed_path\", \"trackers\""
 
 type Torrent struct {
-	Hash            string  `json:"hash"`
-	StatusCode      int     `json:"status_code"`
-	Name            string  `json:"name"`
-	Size            int     `json:"total_size"`
-	PercentProgress float64 `json:"progress"`
-	Downloaded      int     `json:"all_time_download"`
-	Uploaded        int     `json:"total_uploaded"`
-	Ratio           float64 `json:"ratio"`
-	UploadSpeed     int     `json:"upload_payload_rate"`
-	DownloadSpeed   int     `json:"download_payload_rate"`
-	ETA             int     `json:"eta"`
-	Label           string  `json:"label"`
-	PeersConnected  int     `json:"num_peers"`
-	PeersTotal      int     `json:"total_peers"`
-	SeedsConnected  int     `json:"num_seeds"`
-	SeedsTotal      int     `json:"total_seeds"`
-	Availability    float64 `json:"seeds_peers_ratio"`
-	QueueOrder      int     `json:"queue"`
-	Remaining       int     `json:"remaining"`
-	Status          string  `json:"state"`
-	AddedOn         int     `json:"added_on"`
-	CompletedOn     int     `json:"completed_on"`
-	FilePath        string  `json:"move_on_completed_path"`
-	AddedRaw        float64 `json:"time_added"`
+	Hash            string    `json:"hash"`
+	StatusCode      int       `json:"status_code"`
+	Name            string    `json:"name"`
+	Size            int       `json:"total_size"`
+	PercentProgress float64   `json:"progress"`
+	Downloaded      int       `json:"all_time_download"`
+	Uploaded        int       `json:"total_uploaded"`
+	Ratio           float64   `json:"ratio"`
+	UploadSpeed     int       `json:"upload_payload_rate"`
+	DownloadSpeed   int       `json:"download_payload_rate"`
+	ETA             int       `json:"eta"`
+	Label           string    `json:"label"`
+	PeersConnected  int       `json:"num_peers"`
+	PeersTotal      int       `json:"total_peers"`
+	SeedsConnected  int       `json:"num_seeds"`
+	SeedsTotal      int       `json:"total_seeds"`
+	Availability    float64   `json:"seeds_peers_ratio"`
+	QueueOrder      int       `json:"queue"`
+	Remaining       int       `json:"remaining"`
+	Status          string    `json:"state"`
+	AddedOn         int       `json:"added_on"`
+	CompletedOn     int       `json:"completed_on"`
+	FilePath        string    `json:"move_on_completed_path"`
+	AddedRaw        float64   `json:"time_added"`
+	Trackers        []Tracker `json:"trackers"`
+}
+
+// Tracker is a tracker announce URL of a torrent
+type Tracker struct {
+	URL  string `json:"url"`
+	Tier int    `json:"tier"`
+}
+
+// Magnet returns a magnet link for the torrent, listing its trackers in tier
+// order
+func (t Torrent) Magnet() *metainfo.Magnet {
+	m := &metainfo.Magnet{
+		InfoHash: t.Hash,
+		Name:     t.Name,
+		Length:   int64(t.Size),
+	}
+	trackers := append([]Tracker(nil), t.Trackers...)
+	sort.SliceStable(trackers, func(i, j int) bool { return trackers[i].Tier < trackers[j].Tier })
+	for _, tr := range trackers {
+		m.Trackers = append(m.Trackers, tr.URL)
+	}
+	return m
 }
 
 // max is used to bound the remaining value (as it can go negative)
@@ -141,6 +165,7 @@ func (torrents *TorrentsResponse) UnmarshalJSON(b []byte) error {
 			AddedOn:         int(torrent.AddedRaw),
 			CompletedOn:     int(torrent.AddedRaw), // Not Provided
 			FilePath:        torrent.FilePath + "/" + torrent.Name,
+			Trackers:        torrent.Trackers,
 		})
 	}
 	return nil
@@ -153,7 +178,7 @@ func (c *Client) GetTorrents() ([]Torrent, error) {
 	err := c.action("core.get_torrents_status", fmt.Sprintf("{},[%s]",
 		TorrentProperties), &torrents)
 	if err != nil {
-		return nil, fmt.Errorf("Error getting torrents: %s", err.Error())
+		return nil, fmt.Errorf("Error getting torrents: %w", err)
 	}
 
 	return torrents.Torrents, nil
@@ -165,7 +190,7 @@ func (c *Client) GetTorrent(hash string) (Torrent, error) {
 	err := c.action("core.get_torrent_status", fmt.Sprintf("\"%s\",[%s]", hash,
 		TorrentProperties), &torrent)
 	if err != nil {
-		return Torrent{}, fmt.Errorf("Error getting torrents: %s", err.Error())
+		return Torrent{}, fmt.Errorf("Error getting torrents: %w", err)
 	}
 
 	//FixUps
@@ -182,7 +207,7 @@ func (c *Client) PauseTorrent(hash string) error {
 	var res BoolResponse
 	err := c.action("core.pause_torrent", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error pausing torrent: %s", err.Error())
+		return fmt.Errorf("Error pausing torrent: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error pausing torrent: %s", res.Error.Message)
@@ -196,7 +221,7 @@ func (c *Client) UnPauseTorrent(hash string) error {
 	var res BoolResponse
 	err := c.action("core.resume_torrent", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error resuming torrent: %s", err.Error())
+		return fmt.Errorf("Error resuming torrent: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error resuming torrent: %s", res.Error.Message)
@@ -222,7 +247,7 @@ func (c *Client) RecheckTorrent(hash string) error {
 	var res BoolResponse
 	err := c.action("core.force_recheck", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error rechecking torrent: %s", err.Error())
+		return fmt.Errorf("Error rechecking torrent: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error rechecking torrent: %s", res.Error.Message)
@@ -236,7 +261,7 @@ func (c *Client) RemoveTorrent(hash string) error {
 	var res BoolResponse
 	err := c.action("core.remove_torrent", fmt.Sprintf("\"%s\", false", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error removing torrent: %s", err.Error())
+		return fmt.Errorf("Error removing torrent: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error removing torrent: %s", res.Error.Message)
@@ -250,7 +275,7 @@ func (c *Client) RemoveTorrentAndData(hash string) error {
 	var res BoolResponse
 	err := c.action("core.remove_torrent", fmt.Sprintf("\"%s\", true", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error removing torrent: %s", err.Error())
+		return fmt.Errorf("Error removing torrent: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error removing torrent: %s", res.Error.Message)
@@ -259,117 +284,81 @@ func (c *Client) RemoveTorrentAndData(hash string) error {
 	return nil
 }
 
-// AddTorrent adds the torrent specified by url or magnet link
-func (c *Client) AddTorrent(url string) error {
-	var res StringResponse
-	err := c.action("core.add_torrent_magnet", fmt.Sprintf("\"%s\",{}", url), &res)
-	if err != nil {
-		return fmt.Errorf("Error adding torrent: %s", err.Error())
-	}
-	if res.Error.Code != 0 {
-		return fmt.Errorf("Error adding torrent: %s", res.Error.Message)
-	}
-
-	return nil
-}
-
-// AddTorrentFile adds the torrent specified by a file on disk
-func (c *Client) AddTorrentFile(torrentpath string) error {
-	f, err := os.Open(torrentpath)
-	defer f.Close()
-	if err != nil {
-		return fmt.Errorf("Error opening torrent file: %s", err.Error())
-	}
-	blob, err := ioutil.ReadAll(bufio.NewReader(f))
-	if err != nil {
-		return fmt.Errorf("Error reading torrent file: %s", err.Error())
+// RemoveTorrents removes the torrents specified by info hash, and their data
+// if removeData is set. Deluge 2 removes them in a single call; older daemons
+// are sent one core.remove_torrent per torrent, as is a daemon of unknown
+// version that rejects the single call.
+func (c *Client) RemoveTorrents(hashes []string, removeData bool) error {
+	version := c.Version()
+	if !version.IsV1() {
+		failed, err := c.removeTorrentsBatch(hashes, removeData)
+		if err == nil && len(failed) > 0 {
+			return fmt.Errorf("Error removing torrents: %s", strings.Join(failed, "; "))
+		}
+		if err == nil || version.Known() {
+			return err
+		}
+		//Most likely a 1.3 daemon without core.remove_torrents
 	}
 
-	var res StringResponse
-	err = c.action("core.add_torrent_file", fmt.Sprintf("\"%s\", \"%s\",{}",
-		filepath.Base(torrentpath), base64.StdEncoding.EncodeToString(blob)), &res)
-	if err != nil {
-		return fmt.Errorf("Error adding torrent: %s", err.Error())
-	}
-	if res.Error.Code != 0 {
-		return fmt.Errorf("Error adding torrent: %s", res.Error.Message)
+	for _, hash := range hashes {
+		var res BoolResponse
+		err := c.action("core.remove_torrent", fmt.Sprintf("\"%s\", %t", hash, removeData), &res)
+		if err != nil {
+			return fmt.Errorf("Error removing torrent: %w", err)
+		}
+		if res.Error.Code != 0 {
+			return fmt.Errorf("Error removing torrent: %s", res.Error.Message)
+		}
 	}
-
 	return nil
 }
 
-// SetTorrentLabel sets the label for the given torrent
-func (c *Client) SetTorrentLabel(hash string, label string) error {
-	//Deluge only accepts lowercase labels
-	label = strings.ToLower(label)
-
-	var plugins ArrayResponse
-	err := c.action("core.get_enabled_plugins", "", &plugins)
+// removeTorrentsBatch calls core.remove_torrents and returns the failures it
+// reports
+func (c *Client) removeTorrentsBatch(hashes []string, removeData bool) ([]string, error) {
+	p, err := params(hashes, removeData)
 	if err != nil {
-		return fmt.Errorf("Error getting enabled plugins: %s", err.Error())
-	}
-	if plugins.Error.Code != 0 {
-		return fmt.Errorf("Error getting enabled plugins: %s", plugins.Error.Message)
+		return nil, fmt.Errorf("Error removing torrents: %w", err)
 	}
 
-	if !contains(plugins.Result, "Label") {
-		return fmt.Errorf("Label Plugin not detected - are you sure it is enabled?")
+	var res struct {
+		Result [][]interface{} `json:"result"`
+		Error  RpcError        `json:"error"`
 	}
-
-	var res ArrayResponse
-	err = c.action("label.get_labels", "", &res)
+	err = c.action("core.remove_torrents", p, &res)
 	if err != nil {
-		return fmt.Errorf("Error getting torrent labels: %s", err.Error())
+		return nil, fmt.Errorf("Error removing torrents: %w", err)
 	}
 	if res.Error.Code != 0 {
-		return fmt.Errorf("Error getting torrent labels: %s", res.Error.Message)
+		return nil, fmt.Errorf("Error removing torrents: %s", res.Error.Message)
 	}
 
-	if !contains(res.Result, label) {
-		err = c.action("label.add", fmt.Sprintf("\"%s\"", label), &res)
-		if err != nil {
-			return fmt.Errorf("Error creating torrent label: %s", err.Error())
+	//The result lists [hash, error] for each torrent that failed
+	var failed []string
+	for _, entry := range res.Result {
+		if len(entry) == 2 {
+			failed = append(failed, fmt.Sprintf("%v: %v", entry[0], entry[1]))
 		}
-		if res.Error.Code != 0 {
-			return fmt.Errorf("Error creating torrent label: %s", res.Error.Message)
-		}
-	}
-
-	err = c.action("label.set_torrent", fmt.Sprintf("\"%s\", \"%s\"", hash, label), &res)
-	if err != nil {
-		return fmt.Errorf("Error setting torrent label: %s", err.Error())
-	}
-	if res.Error.Code != 0 {
-		return fmt.Errorf("Error setting torrent label: %s", res.Error.Message)
 	}
-
-	return nil
+	return failed, nil
 }
 
-// SetTorrentSeedRatio sets the seed ratio for the given torrent
+// SetTorrentSeedRatio pauses the given torrent once it reaches ratio
 func (c *Client) SetTorrentSeedRatio(hash string, ratio float64) error {
-	var res BoolResponse
-	err := c.action("core.set_torrent_options",
-		fmt.Sprintf("[\"%s\"],{\"stop_at_ratio\": true, \"stop_ratio\": %f}", hash, ratio), &res)
-	if err != nil {
-		return fmt.Errorf("Error setting torrent queue priority: %s", err.Error())
-	}
-	if res.Error.Code != 0 {
-		return fmt.Errorf("Error setting torrent queue priority: %s", res.Error.Message)
-	}
-
-	return nil
+	return c.SetTorrentRatioPolicy([]string{hash}, RatioPolicy{Enabled: true, Ratio: ratio})
 }
 
-// SetTorrentSeedTime sets the seed time for the given torrent
+// SetTorrentSeedTime sets the seed time, in seconds, for the given torrent.
+// Deluge has no concept of stopping after seeding for a specific time, so the
+// target is recorded in the SeedTimeLimiter attached to the client, which
+// pauses or removes the torrent once it is reached.
 func (c *Client) SetTorrentSeedTime(hash string, time int) error {
-	//Deluge does not have a concept of stop after seeding for a specific time
-	err := errors.New("Not Implemented")
-	if err != nil {
-		return err
+	if c.seedTime == nil {
+		return ErrNoSeedTimeLimiter
 	}
 
-	return nil
+	return c.seedTime.Set(hash, time)
 }
 
 // QueueTop sends the torrent to the top of the download queue
@@ -377,7 +366,7 @@ func (c *Client) QueueTop(hash string) error {
 	var res BoolResponse
 	err := c.action("core.queue_top", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error setting torrent queue priority: %s", err.Error())
+		return fmt.Errorf("Error setting torrent queue priority: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error setting torrent queue priority: %s", res.Error.Message)
@@ -391,7 +380,7 @@ func (c *Client) QueueUp(hash string) error {
 	var res BoolResponse
 	err := c.action("core.queue_up", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error setting torrent queue priority: %s", err.Error())
+		return fmt.Errorf("Error setting torrent queue priority: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error setting torrent queue priority: %s", res.Error.Message)
@@ -405,7 +394,7 @@ func (c *Client) QueueDown(hash string) error {
 	var res BoolResponse
 	err := c.action("core.queue_down", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error setting torrent queue priority: %s", err.Error())
+		return fmt.Errorf("Error setting torrent queue priority: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error setting torrent queue priority: %s", res.Error.Message)
@@ -419,7 +408,7 @@ func (c *Client) QueueBottom(hash string) error {
 	var res BoolResponse
 	err := c.action("core.queue_bottom", fmt.Sprintf("[\"%s\"]", hash), &res)
 	if err != nil {
-		return fmt.Errorf("Error setting torrent queue priority: %s", err.Error())
+		return fmt.Errorf("Error setting torrent queue priority: %w", err)
 	}
 	if res.Error.Code != 0 {
 		return fmt.Errorf("Error setting torrent queue priority: %s", res.Error.Message)
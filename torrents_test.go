@@ -0,0 +1,82 @@
+package deluge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const torrentsSample = `{"id": 3, "error": null, "result": {
+	"414e6d9bab12b00e182c996e601341300b9cdc72": {
+		"hash": "414e6d9bab12b00e182c996e601341300b9cdc72",
+		"name": "sample",
+		"total_size": 32780,
+		"all_time_download": 780,
+		"time_added": 1500000000.5,
+		"state": "Downloading",
+		"move_on_completed_path": "/done",
+		"trackers": [
+			{"url": "udp://backup:80", "tier": 1, "send_stats": true},
+			{"url": "http://main/announce", "tier": 0, "send_stats": true}
+		]
+	}
+}}`
+
+func TestTorrentsResponseDecode(t *testing.T) {
+	var res TorrentsResponse
+	if err := json.Unmarshal([]byte(torrentsSample), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Torrents) != 1 {
+		t.Fatalf("Torrents = %v", res.Torrents)
+	}
+
+	torrent := res.Torrents[0]
+	if torrent.Name != "sample" || torrent.Size != 32780 || torrent.Remaining != 32000 {
+		t.Errorf("torrent = %+v", torrent)
+	}
+	if torrent.AddedOn != 1500000000 || torrent.FilePath != "/done/sample" || torrent.Status != "Downloading" {
+		t.Errorf("torrent = %+v", torrent)
+	}
+	wantTrackers := []Tracker{{URL: "udp://backup:80", Tier: 1}, {URL: "http://main/announce", Tier: 0}}
+	if !reflect.DeepEqual(torrent.Trackers, wantTrackers) {
+		t.Errorf("Trackers = %v, want %v", torrent.Trackers, wantTrackers)
+	}
+
+	m := torrent.Magnet()
+	want := "magnet:?xt=urn:btih:414e6d9bab12b00e182c996e601341300b9cdc72&dn=sample&xl=32780" +
+		"&tr=http%3A%2F%2Fmain%2Fannounce&tr=udp%3A%2F%2Fbackup%3A80"
+	if m.String() != want {
+		t.Errorf("Magnet() = %s, want %s", m, want)
+	}
+}
+
+func TestGetTorrentsTrackers(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_torrents_status", func([]json.RawMessage) interface{} {
+		var res struct {
+			Result json.RawMessage `json:"result"`
+		}
+		json.Unmarshal([]byte(torrentsSample), &res)
+		return res.Result
+	})
+	c := newTestClient(t, f)
+
+	torrents, err := c.GetTorrents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(torrents) != 1 || len(torrents[0].Trackers) != 2 {
+		t.Fatalf("GetTorrents = %+v", torrents)
+	}
+
+	var keys []string
+	json.Unmarshal(f.called("core.get_torrents_status")[0].Params[1], &keys)
+	found := false
+	for _, key := range keys {
+		found = found || key == "trackers"
+	}
+	if !found {
+		t.Errorf("trackers not requested: %v", keys)
+	}
+}
@@ -0,0 +1,118 @@
+package deluge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DaemonVersion is the version of the daemon deluge-web is connected to.
+// The zero value means the version is unknown, in which case methods that
+// differ between versions try the Deluge 2 form first and fall back.
+type DaemonVersion struct {
+	// Deluge is the version reported by daemon.info, e.g. "2.1.1"
+	Deluge     string
+	Libtorrent string
+	Major      int
+	Minor      int
+}
+
+// Known reports whether the version was detected
+func (v DaemonVersion) Known() bool {
+	return v.Major > 0
+}
+
+// IsV2 reports whether the daemon is Deluge 2 or later
+func (v DaemonVersion) IsV2() bool {
+	return v.Major >= 2
+}
+
+// IsV1 reports whether the daemon is a detected Deluge 1.x
+func (v DaemonVersion) IsV1() bool {
+	return v.Major == 1
+}
+
+func (v DaemonVersion) String() string {
+	if !v.Known() {
+		return "unknown"
+	}
+	if v.Libtorrent == "" {
+		return "Deluge " + v.Deluge
+	}
+	return fmt.Sprintf("Deluge %s, libtorrent %s", v.Deluge, v.Libtorrent)
+}
+
+// parseDaemonVersion reads the major and minor numbers from versions such as
+// "1.3.15" or "2.0.3-2-201906121747-ubuntu18.04.1"
+func parseDaemonVersion(s string) (int, int, error) {
+	parts := strings.SplitN(s, ".", 3)
+	numbers := make([]int, 2)
+	for i := range numbers {
+		if i >= len(parts) {
+			break
+		}
+		digits := strings.TrimLeft(parts[i], " v")
+		end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' })
+		if end >= 0 {
+			digits = digits[:end]
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unrecognised version %q", s)
+		}
+		numbers[i] = n
+	}
+	return numbers[0], numbers[1], nil
+}
+
+// DetectVersion asks the daemon for its version and stores it for Version
+// and the methods that depend on it. NewClient calls it once; call it again
+// after switching deluge-web to another daemon. The cached method and plugin
+// lists are dropped, as they may belong to the previous daemon. If detection
+// fails the stored version is cleared, so Version is no longer Known.
+func (c *Client) DetectVersion() (DaemonVersion, error) {
+	c.InvalidateMethods()
+	c.InvalidatePlugins()
+
+	info, err := c.GetDaemonInfo()
+	if err != nil {
+		c.setVersion(DaemonVersion{})
+		return DaemonVersion{}, err
+	}
+
+	var v DaemonVersion
+	v.Deluge = info
+	v.Major, v.Minor, err = parseDaemonVersion(info)
+	if err != nil {
+		c.setVersion(DaemonVersion{})
+		return DaemonVersion{}, fmt.Errorf("Error getting daemon info: %w", err)
+	}
+	//The libtorrent version is informational only
+	v.Libtorrent, _ = c.GetLibtorrentVersion()
+	c.setVersion(v)
+
+	return v, nil
+}
+
+// setVersion stores the version returned by Version
+func (c *Client) setVersion(v DaemonVersion) {
+	c.versionMu.Lock()
+	c.version = v
+	c.versionMu.Unlock()
+}
+
+// Version returns the daemon version detected by NewClient or DetectVersion
+func (c *Client) Version() DaemonVersion {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+
+	return c.version
+}
+
+// savePathKey is the torrent status key holding the download directory
+func (c *Client) savePathKey() string {
+	if c.Version().IsV2() {
+		return "download_location"
+	}
+	return "save_path"
+}
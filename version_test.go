@@ -0,0 +1,143 @@
+package deluge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDaemonVersion(t *testing.T) {
+	tests := []struct {
+		version      string
+		major, minor int
+	}{
+		{"1.3.15", 1, 3},
+		{"2.0.3-2-201906121747-ubuntu18.04.1", 2, 0},
+		{"2.1.1", 2, 1},
+		{"2", 2, 0},
+		{"v2.1", 2, 1},
+	}
+
+	for _, tt := range tests {
+		major, minor, err := parseDaemonVersion(tt.version)
+		if err != nil || major != tt.major || minor != tt.minor {
+			t.Errorf("parseDaemonVersion(%q) = %d, %d, %v, want %d, %d", tt.version, major, minor, err, tt.major, tt.minor)
+		}
+	}
+	if _, _, err := parseDaemonVersion("unknown"); err == nil {
+		t.Errorf("expected an error for an unrecognised version")
+	}
+}
+
+func TestRemoveTorrentsVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		batch   bool
+		single  int
+	}{
+		{"deluge 2", "2.1.1", true, 0},
+		{"deluge 1.3", "1.3.15", false, 2},
+		{"unknown with core.remove_torrents", "", true, 0},
+		{"unknown without core.remove_torrents", "", false, 2},
+	}
+
+	for _, tt := range tests {
+		f := newFakeDeluge(tt.version)
+		if tt.version == "" {
+			f.handle("daemon.info", func([]json.RawMessage) interface{} {
+				return RpcError{Message: "Not connected", Code: 1}
+			})
+		}
+		//A 1.3 daemon does not have the batch call
+		if tt.batch {
+			f.handle("core.remove_torrents", func([]json.RawMessage) interface{} { return []interface{}{} })
+		}
+		f.handle("core.remove_torrent", func([]json.RawMessage) interface{} { return true })
+		c := newTestClient(t, f)
+
+		err := c.RemoveTorrents([]string{"a", "b"}, true)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if n := len(f.called("core.remove_torrents")); (n == 1) != tt.batch {
+			t.Errorf("%s: core.remove_torrents called %d times", tt.name, n)
+		}
+		if n := len(f.called("core.remove_torrent")); n != tt.single {
+			t.Errorf("%s: core.remove_torrent called %d times, want %d", tt.name, n, tt.single)
+		}
+	}
+}
+
+func TestRemoveTorrentsFailures(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.remove_torrents", func([]json.RawMessage) interface{} {
+		return [][]string{{"b", "Torrent not found"}}
+	})
+	f.handle("core.remove_torrent", func([]json.RawMessage) interface{} { return true })
+	c := newTestClient(t, f)
+
+	err := c.RemoveTorrents([]string{"a", "b"}, false)
+	if err == nil || err.Error() != "Error removing torrents: b: Torrent not found" {
+		t.Errorf("RemoveTorrents error = %v", err)
+	}
+	if n := len(f.called("core.remove_torrent")); n != 0 {
+		t.Errorf("reported failures fell back to core.remove_torrent")
+	}
+}
+
+func TestDetectVersionFails(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	c := newTestClient(t, f)
+	if !c.Version().IsV2() {
+		t.Fatalf("Version = %v", c.Version())
+	}
+
+	//A daemon that cannot be asked must not keep the previous daemon's version
+	f.handle("daemon.info", func([]json.RawMessage) interface{} {
+		return RpcError{Message: "Not connected", Code: 1}
+	})
+	if _, err := c.DetectVersion(); err == nil {
+		t.Fatal("DetectVersion succeeded")
+	}
+	if c.Version().Known() {
+		t.Errorf("Version after a failed DetectVersion = %v", c.Version())
+	}
+
+	f.handle("daemon.info", func([]json.RawMessage) interface{} { return "2.1.1" })
+	c.DetectVersion()
+	f.handle("daemon.info", func([]json.RawMessage) interface{} { return "dev" })
+	if _, err := c.DetectVersion(); err == nil {
+		t.Fatal("DetectVersion accepted an unrecognised version")
+	}
+	if c.Version().Known() {
+		t.Errorf("Version after an unrecognised version = %v", c.Version())
+	}
+}
+
+func TestDetectVersionInvalidatesCaches(t *testing.T) {
+	f := newFakeDeluge("2.1.1")
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{"Label"} })
+	c := newTestClient(t, f)
+	if _, err := c.RefreshPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	methods := len(f.called("daemon.get_method_list"))
+
+	//deluge-web switched to a 1.3 daemon without the Label plugin
+	f.handle("daemon.info", func([]json.RawMessage) interface{} { return "1.3.15" })
+	f.handle("core.get_enabled_plugins", func([]json.RawMessage) interface{} { return []string{} })
+	v, err := c.DetectVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsV1() || !c.Version().IsV1() {
+		t.Errorf("DetectVersion = %v", v)
+	}
+
+	if n := len(f.called("daemon.get_method_list")); n != methods+1 {
+		t.Errorf("method list not fetched again after DetectVersion")
+	}
+	if err := c.requirePlugin("Label"); err == nil {
+		t.Errorf("plugin list not fetched again after DetectVersion")
+	}
+}